@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	target, err := parseArgs([]string{}, io.Discard)
+	if err != nil || target != nil {
+		t.Fatalf("Expected no target without arguments, got %#v %v", target, err)
+	}
+
+	target, err = parseArgs([]string{"postgres://admin@db.internal/shop", "--table", "public.orders"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse arguments: %s", err)
+	}
+	if target.host.Host != "db.internal" || target.selection.Database != "shop" || target.selection.Table != "public.orders" {
+		t.Fatalf("Incorrect target `%#v`", target)
+	}
+
+	target, err = parseArgs([]string{"--db", "other", "mysql://root@localhost/app"}, io.Discard)
+	if err != nil {
+		t.Fatalf("Failed to parse arguments: %s", err)
+	}
+	if target.host.DbType != "mysql" || target.selection.Database != "other" {
+		t.Fatalf("--db should override the database of the url `%#v`", target)
+	}
+
+	for _, args := range [][]string{
+		{"--table", "orders"},
+		{"--host", "saved", "postgres://localhost"},
+		{"postgres://localhost", "mysql://localhost"},
+		{"--unknown"},
+	} {
+		if _, err := parseArgs(args, io.Discard); err == nil {
+			t.Fatalf("Expected an error for %v", args)
+		}
+	}
+}
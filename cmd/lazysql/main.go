@@ -2,12 +2,15 @@ package main
 
 import (
 	goContext "context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"strings"
 	"time"
 
+	_ "github.com/Kavantix/lazysql/internal/database/drivers"
 	"github.com/Kavantix/lazysql/internal/gui"
 	"github.com/Kavantix/lazysql/internal/layouts"
 
@@ -18,9 +21,18 @@ import (
 )
 
 func main() {
+	target, err := parseArgs(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
 	setTitle("")
 
-	err := godotenv.Load()
+	err = godotenv.Load()
 	checkErr(err)
 
 	g, err := gocui.NewGui(gocui.OutputTrue, true)
@@ -33,7 +45,11 @@ func main() {
 	g.Highlight = true
 
 	context := layouts.New(&mainContext{g: g})
-	context.ShowConfigLayout()
+	if target == nil {
+		context.ShowConfigLayout()
+	} else {
+		context.Open(target.host, target.selection)
+	}
 
 	if err := g.MainLoop(); err != nil && err != gocui.ErrQuit {
 		log.Panicln(err)
@@ -121,6 +137,21 @@ func (c *mainContext) ShowPopup(title, message string, color gocui.Attribute) {
 	c.popupView.Show(title, message, color)
 }
 
+func (c *mainContext) ShowChoices(title, message string, choices []gui.Choice) {
+	c.popupView.ShowChoices(title, message, gocui.ColorYellow+8, choices)
+}
+
+func (c *mainContext) Confirm(message string, onConfirm func() error) {
+	c.ShowChoices("Confirm", message, []gui.Choice{
+		{Key: 'y', Label: "Yes", OnChoose: onConfirm},
+		{Key: 'n', Label: "No", OnChoose: func() error { return nil }},
+	})
+}
+
+func (c *mainContext) Prompt(title, message string, masked bool, onSubmit func(value string)) {
+	c.popupView.Prompt(title, message, gocui.ColorYellow+8, masked, onSubmit)
+}
+
 func checkErr(err error) {
 	if err != nil {
 		log.Panicln(err)
@@ -0,0 +1,133 @@
+// Package credentials resolves connection settings that are missing from the config
+// using the files and environment variables the command line clients of databases use
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type Field string
+
+const (
+	FieldHost     Field = "host"
+	FieldPort     Field = "port"
+	FieldUser     Field = "user"
+	FieldPassword Field = "password"
+)
+
+// Credentials are the settings needed to connect to a host, empty fields are unknown
+type Credentials struct {
+	Host     string
+	Port     int
+	User     string
+	Password string
+	// Service is the name of the section in the postgres service file to use
+	Service string
+	// Sources describes where every resolved field was found, e.g. ~/.pgpass
+	// Fields that were set before resolving are not included
+	Sources map[Field]string
+}
+
+// Environment is where credentials are looked up
+type Environment struct {
+	Getenv  func(key string) string
+	HomeDir string
+}
+
+// DefaultEnvironment is the environment of the current process
+func DefaultEnvironment() Environment {
+	homedir, _ := os.UserHomeDir()
+	return Environment{
+		Getenv:  os.Getenv,
+		HomeDir: homedir,
+	}
+}
+
+// path returns path with a leading ~ replaced by the home directory
+func (e Environment) path(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(e.HomeDir, path[1:])
+	}
+	return path
+}
+
+// displayPath returns path with the home directory replaced by ~
+func (e Environment) displayPath(path string) string {
+	if e.HomeDir != "" && strings.HasPrefix(path, e.HomeDir+string(os.PathSeparator)) {
+		return "~" + path[len(e.HomeDir):]
+	}
+	return path
+}
+
+// set sets field to value when it is still empty and records source as where it was found
+func (c *Credentials) set(field Field, value, source string) {
+	if value == "" {
+		return
+	}
+	switch field {
+	case FieldHost:
+		if c.Host != "" {
+			return
+		}
+		c.Host = value
+	case FieldPort:
+		port, err := strconv.Atoi(value)
+		if c.Port != 0 || err != nil || port < 1 || port > 65535 {
+			return
+		}
+		c.Port = port
+	case FieldUser:
+		if c.User != "" {
+			return
+		}
+		c.User = value
+	case FieldPassword:
+		if c.Password != "" {
+			return
+		}
+		c.Password = value
+	}
+	if c.Sources == nil {
+		c.Sources = map[Field]string{}
+	}
+	c.Sources[field] = source
+}
+
+// iniSection is a section of an ini file like the postgres service file and the mysql option file
+type iniSection map[string]string
+
+// parseIni parses the sections of an ini file, keys before the first section are ignored
+// Values can be quoted, dashes in keys are read as underscores
+func parseIni(content string) map[string]iniSection {
+	sections := map[string]iniSection{}
+	var section iniSection
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			section = sections[name]
+			if section == nil {
+				section = iniSection{}
+				sections[name] = section
+			}
+			continue
+		}
+		if section == nil {
+			continue
+		}
+		key, value, _ := strings.Cut(line, "=")
+		key = strings.ReplaceAll(strings.TrimSpace(key), "-", "_")
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		section[key] = value
+	}
+	return sections
+}
@@ -0,0 +1,171 @@
+package credentials
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testEnvironment(t *testing.T, variables map[string]string) Environment {
+	return Environment{
+		Getenv:  func(key string) string { return variables[key] },
+		HomeDir: t.TempDir(),
+	}
+}
+
+func writeFile(t *testing.T, path, content string, mode os.FileMode) {
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("Failed to write %s: %s", path, err)
+	}
+}
+
+func TestPostgresEnvironment(t *testing.T) {
+	env := testEnvironment(t, map[string]string{
+		"PGHOST": "db.internal",
+		"PGPORT": "6543",
+		"PGUSER": "reporter",
+	})
+	c, err := Postgres(Credentials{User: "admin"}, env)
+	if err != nil {
+		t.Fatalf("Failed to resolve credentials: %s", err)
+	}
+	if c.Host != "db.internal" || c.Port != 6543 || c.User != "admin" {
+		t.Fatalf("Incorrect credentials `%#v`", c)
+	}
+	if c.Sources[FieldHost] != "PGHOST" || c.Sources[FieldPort] != "PGPORT" {
+		t.Fatalf("Incorrect sources `%#v`", c.Sources)
+	}
+	if _, ok := c.Sources[FieldUser]; ok {
+		t.Fatalf("Configured user should not have a source")
+	}
+}
+
+func TestPostgresPassFile(t *testing.T) {
+	env := testEnvironment(t, map[string]string{})
+	writeFile(t, filepath.Join(env.HomeDir, ".pgpass"), `
+# hostname:port:database:username:password
+other.internal:5432:*:admin:wrong
+db.internal:*:admin:admin:pass\:word
+*:*:*:*:fallback
+`, 0600)
+
+	c, err := Postgres(Credentials{Host: "db.internal", Port: 5432, User: "admin"}, env)
+	if err != nil {
+		t.Fatalf("Failed to resolve credentials: %s", err)
+	}
+	if c.Password != "pass:word" || c.Sources[FieldPassword] != "~/.pgpass" {
+		t.Fatalf("Incorrect password `%#v`", c)
+	}
+
+	c, err = Postgres(Credentials{Host: "elsewhere", User: "someone"}, env)
+	if err != nil {
+		t.Fatalf("Failed to resolve credentials: %s", err)
+	}
+	if c.Password != "fallback" {
+		t.Fatalf("Wildcards should match `%#v`", c)
+	}
+
+	c, err = Postgres(Credentials{Host: "db.internal", User: "admin", Password: "configured"}, env)
+	if err != nil || c.Password != "configured" {
+		t.Fatalf("Configured password should be kept `%#v`", c)
+	}
+
+	os.Chmod(filepath.Join(env.HomeDir, ".pgpass"), 0644)
+	c, err = Postgres(Credentials{Host: "db.internal", User: "admin"}, env)
+	if err != nil || c.Password != "" {
+		t.Fatalf("Password file readable by others should be ignored `%#v`", c)
+	}
+}
+
+func TestPostgresPassFileDatabase(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".pgpass"), `
+db.internal:5432:admin:admin:default-database
+db.internal:5432:app:admin:app
+db.internal:5432:*:admin:any-database
+`, 0600)
+	tests := []struct {
+		database string
+		expected string
+	}{
+		{"", "default-database"},
+		{"app", "app"},
+		{"reports", "any-database"},
+	}
+	for _, test := range tests {
+		env := Environment{
+			Getenv: func(key string) string {
+				if key == "PGDATABASE" {
+					return test.database
+				}
+				return ""
+			},
+			HomeDir: home,
+		}
+		c, err := Postgres(Credentials{Host: "db.internal", User: "admin"}, env)
+		if err != nil {
+			t.Fatalf("Failed to resolve credentials: %s", err)
+		}
+		if c.Password != test.expected {
+			t.Fatalf("Expected password %s for database %q, got `%#v`", test.expected, test.database, c)
+		}
+	}
+}
+
+func TestPostgresService(t *testing.T) {
+	servicePath := filepath.Join(t.TempDir(), "services.conf")
+	writeFile(t, servicePath, `
+[reporting]
+host=reports.internal
+port=5433
+user=reporter
+password=secret
+`, 0600)
+	env := testEnvironment(t, map[string]string{
+		"PGSERVICEFILE": servicePath,
+		"PGSERVICE":     "reporting",
+		"PGHOST":        "db.internal",
+	})
+	c, err := Postgres(Credentials{}, env)
+	if err != nil {
+		t.Fatalf("Failed to resolve credentials: %s", err)
+	}
+	if c.Host != "reports.internal" || c.Port != 5433 || c.User != "reporter" || c.Password != "secret" {
+		t.Fatalf("Service should take precedence over the environment `%#v`", c)
+	}
+	if c.Sources[FieldHost] != servicePath+" [reporting]" {
+		t.Fatalf("Incorrect source `%s`", c.Sources[FieldHost])
+	}
+
+	if _, err := Postgres(Credentials{Service: "missing"}, env); err == nil {
+		t.Fatalf("Expected an error for a missing service")
+	}
+}
+
+func TestMysqlOptionFile(t *testing.T) {
+	env := testEnvironment(t, map[string]string{})
+	c, err := Mysql(Credentials{Host: "db.internal"}, env)
+	if err != nil || c.Password != "" {
+		t.Fatalf("A missing option file should be ignored `%#v` %v", c, err)
+	}
+
+	writeFile(t, filepath.Join(env.HomeDir, ".my.cnf"), `
+[mysql]
+user=wrong
+
+[client]
+user = admin
+password = "s3cr=t"
+port=3307
+`, 0600)
+	c, err = Mysql(Credentials{Host: "db.internal"}, env)
+	if err != nil {
+		t.Fatalf("Failed to resolve credentials: %s", err)
+	}
+	if c.Host != "db.internal" || c.Port != 3307 || c.User != "admin" || c.Password != "s3cr=t" {
+		t.Fatalf("Incorrect credentials `%#v`", c)
+	}
+	if c.Sources[FieldPassword] != "~/.my.cnf [client]" {
+		t.Fatalf("Incorrect source `%s`", c.Sources[FieldPassword])
+	}
+}
@@ -0,0 +1,30 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Mysql fills the empty fields of c from the [client] section of ~/.my.cnf
+func Mysql(c Credentials, env Environment) (Credentials, error) {
+	path := env.path("~/.my.cnf")
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("failed to read option file: %w", err)
+	}
+	section, ok := parseIni(string(content))["client"]
+	if !ok {
+		return c, nil
+	}
+	source := env.displayPath(path) + " [client]"
+	c.set(FieldHost, section["host"], source)
+	c.set(FieldPort, section["port"], source)
+	c.set(FieldUser, section["user"], source)
+	c.set(FieldPassword, section["password"], source)
+	return c, nil
+}
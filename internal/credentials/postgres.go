@@ -0,0 +1,147 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Postgres fills the empty fields of c like libpq would
+// Fields are taken from the service file first, then the PG environment variables,
+// the password is looked up in the password file last
+func Postgres(c Credentials, env Environment) (Credentials, error) {
+	if err := postgresService(&c, env); err != nil {
+		return c, err
+	}
+	for field, key := range map[Field]string{
+		FieldHost:     "PGHOST",
+		FieldPort:     "PGPORT",
+		FieldUser:     "PGUSER",
+		FieldPassword: "PGPASSWORD",
+	} {
+		c.set(field, env.Getenv(key), key)
+	}
+	if c.Password == "" {
+		if err := postgresPassFile(&c, env); err != nil {
+			return c, err
+		}
+	}
+	return c, nil
+}
+
+// postgresService applies the section of the service named by c.Service or PGSERVICE
+func postgresService(c *Credentials, env Environment) error {
+	service := c.Service
+	if service == "" {
+		service = env.Getenv("PGSERVICE")
+	}
+	if service == "" {
+		return nil
+	}
+	path := env.Getenv("PGSERVICEFILE")
+	if path == "" {
+		path = env.path("~/.pg_service.conf")
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read service file: %w", err)
+	}
+	section, ok := parseIni(string(content))[service]
+	if !ok {
+		return fmt.Errorf("service %s not found in %s", service, env.displayPath(path))
+	}
+	source := fmt.Sprintf("%s [%s]", env.displayPath(path), service)
+	c.set(FieldHost, section["host"], source)
+	c.set(FieldPort, section["port"], source)
+	c.set(FieldUser, section["user"], source)
+	c.set(FieldPassword, section["password"], source)
+	return nil
+}
+
+// postgresPassFile looks up the password in PGPASSFILE or ~/.pgpass
+// Like libpq the file is ignored when it can be read by others
+func postgresPassFile(c *Credentials, env Environment) error {
+	path := env.Getenv("PGPASSFILE")
+	if path == "" {
+		path = env.path("~/.pgpass")
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	host, port, username := c.Host, c.Port, c.User
+	if host == "" {
+		host = "localhost"
+	}
+	if port == 0 {
+		port = 5432
+	}
+	if username == "" {
+		if current, err := user.Current(); err == nil {
+			username = current.Username
+		}
+	}
+	// The first connection opens PGDATABASE, the server defaults to the database named after the user
+	database := env.Getenv("PGDATABASE")
+	if database == "" {
+		database = username
+	}
+	wanted := []string{host, strconv.Itoa(port), database, username}
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		fields := splitPassLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		matches := true
+		for i, value := range wanted {
+			if fields[i] != "*" && fields[i] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			c.set(FieldPassword, fields[4], env.displayPath(path))
+			return nil
+		}
+	}
+	return nil
+}
+
+// splitPassLine splits a line of a password file on colons, which can be escaped with a backslash
+func splitPassLine(line string) []string {
+	fields := []string{}
+	field := strings.Builder{}
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':' && len(fields) < 4:
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, field.String())
+}
@@ -0,0 +1,32 @@
+package database
+
+import "time"
+
+// Session is a connection to the server as listed by the activity monitor
+type Session struct {
+	// ID identifies the session to cancel or terminate it, like the pid of a Postgres backend
+	ID       int64
+	User     string
+	Database string
+	// State is what the session is doing, like active, idle or Query
+	State string
+	// Duration is how long the current or last statement has been running,
+	// only meaningful when HasDuration is true
+	Duration    time.Duration
+	HasDuration bool
+	// WaitEvent is what the session is waiting for, empty when it is not waiting
+	WaitEvent string
+	Query     string
+}
+
+// ActivityMonitor is implemented by drivers of servers that can list and stop the sessions connected to them
+type ActivityMonitor interface {
+	// Sessions lists the sessions connected to the server, except the one used to list them
+	Sessions() ([]Session, error)
+
+	// CancelSession cancels the statement session id is running, the session stays connected
+	CancelSession(id int64) error
+
+	// TerminateSession closes the connection of session id
+	TerminateSession(id int64) error
+}
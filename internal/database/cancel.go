@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// serverCancelTimeout limits how long asking the server to cancel a statement can take
+const serverCancelTimeout = 5 * time.Second
+
+// CancelOutcome describes what happened when cancelling a query
+type CancelOutcome uint8
+
+const (
+	// CancelNothingRunning means there was no query to cancel
+	CancelNothingRunning CancelOutcome = iota
+	// CancelLocal means only the client stopped the query,
+	// the driver cannot ask the server to cancel statements
+	CancelLocal
+	// CancelConfirmed means the server confirmed it cancelled the statement
+	CancelConfirmed
+	// CancelUnconfirmed means the server was asked to cancel the statement but did not confirm it,
+	// it could still be running
+	CancelUnconfirmed
+)
+
+// CancelQuery cancels any running query, on the server first when the driver supports it,
+// an error is returned when asking the server failed
+// In a transaction the client only stops waiting when the server did not confirm the cancel,
+// since that closes the connection and with it the transaction
+func (b *BaseDriver) CancelQuery() (CancelOutcome, error) {
+	b.queryMutex.Lock()
+	cancel := b.cancelFunc
+	connectionID := b.connectionID
+	inTransaction := b.transaction != nil
+	b.queryMutex.Unlock()
+	if cancel == nil {
+		return CancelNothingRunning, nil
+	}
+	if connectionID == 0 || b.CancelOnServer == nil {
+		cancel()
+		return CancelLocal, nil
+	}
+	confirmed, err := b.cancelOnServer(connectionID)
+	if err != nil || !confirmed {
+		cancel()
+		return CancelUnconfirmed, err
+	}
+	if !inTransaction {
+		// The client stops waiting right away instead of waiting for the statement to end
+		cancel()
+	}
+	return CancelConfirmed, nil
+}
+
+// cancelOnServer asks the server to cancel the statement running in the session with connectionID
+func (b *BaseDriver) cancelOnServer(connectionID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), serverCancelTimeout)
+	defer cancel()
+	return b.CancelOnServer(ctx, b.Db, connectionID)
+}
+
+// trackConnection remembers the server session of conn as the one running the query of ctx,
+// so CancelQuery can ask the server to cancel it
+func (b *BaseDriver) trackConnection(ctx context.Context, conn *sql.Conn) int64 {
+	if b.ConnectionID == nil || b.CancelOnServer == nil {
+		return 0
+	}
+	id, err := b.ConnectionID(ctx, conn)
+	if err != nil {
+		// The query can still be cancelled by the client
+		return 0
+	}
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.context == ctx {
+		b.connectionID = id
+	}
+	return id
+}
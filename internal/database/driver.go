@@ -3,8 +3,11 @@ package database
 import (
 	"context"
 	"database/sql"
-	"strings"
+	"errors"
+	"net"
+	"reflect"
 	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -12,11 +15,84 @@ type Dsn struct {
 	Host           string
 	Port           uint16
 	User, Password string
+	// File is the path of the database for file based databases like sqlite
+	File string
+	// Dialer opens the connections to the server when set, e.g. through an ssh tunnel
+	Dialer Dialer
+	TLS    TLS
+	// QueryTimeout cancels queries that run longer, 0 disables the timeout
+	QueryTimeout time.Duration
 }
 
+// Dialer opens connections to a database server in a custom way
+// The driver closes the dialer when it is closed
+type Dialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+	Close() error
+}
+
+// ColumnKind is a broad classification of a column type
+// that determines how its values are displayed
+type ColumnKind uint8
+
+const (
+	KindText ColumnKind = iota
+	KindNumber
+	KindBool
+	KindTime
+	KindBinary
+)
+
+type Column struct {
+	Name string
+	// DatabaseType is the name of the type as reported by the database, e.g. VARCHAR or INT4
+	DatabaseType string
+	Kind         ColumnKind
+	// Nullable is only meaningful when HasNullable is true,
+	// not every driver knows whether a column can contain NULL
+	Nullable, HasNullable bool
+	// ScanType is the Go type the driver would scan values of this column into
+	ScanType reflect.Type
+}
+
+// Cell is a single formatted value of a row
+type Cell struct {
+	Value string
+	Null  bool
+}
+
+func (c Cell) String() string {
+	if c.Null {
+		return "NULL"
+	}
+	return c.Value
+}
+
+// ErrResultClosed is returned by FetchMore when the rows of the result were closed,
+// like when another query was started
+var ErrResultClosed = errors.New("the rows of this result are no longer available")
+
+// PageSize is the amount of rows fetched at once by Query and FetchMore
+const PageSize = 1000
+
 type QueryResult struct {
-	Columns []string
-	Data    [][]string
+	Columns []Column
+	// Rows contains the first page of rows, use Driver.FetchMore to load the rest
+	Rows [][]Cell
+	// HasMore is true when the first page was filled, meaning there might be more rows
+	// It is not updated after Query returned, FetchMore reports whether there are still more rows
+	HasMore bool
+	// Duration is how long the query took to return its first page of rows
+	Duration time.Duration
+}
+
+// ColumnNames returns the names of all columns in the result
+func (r *QueryResult) ColumnNames() []string {
+	names := make([]string, len(r.Columns))
+	for i, column := range r.Columns {
+		names[i] = column.Name
+	}
+	return names
 }
 
 type Query string
@@ -24,9 +100,14 @@ type Database string
 type Table interface {
 	DisplayString() string
 	EqualsTable(other Table) bool
+	// Kind returns what kind of object the table is, e.g. a view
+	Kind() ObjectKind
 }
 
 type Driver interface {
+	// Dialect returns the flavour of sql the database speaks
+	Dialect() Dialect
+
 	// Databases queries the connected instance for which databases exist
 	Databases() ([]Database, error)
 
@@ -35,21 +116,73 @@ type Driver interface {
 	// Returns an error if changing the database failed
 	SelectDatabase(db Database) error
 
-	// Tables queries the database for which tables exist
+	// Tables queries the database for which tables exist,
+	// including other objects like views and functions
 	// A database needs to be selected already using SelectDatabase
 	Tables() ([]Table, error)
 
-	// QueryForTable returns the initial select query for table
-	// The given limit will be added to the select
-	QueryForTable(table Table, limit int) Query
+	// QueryForTable returns the initial query for table,
+	// which depends on its kind, e.g. the definition of a function
+	QueryForTable(table Table) Query
 
-	// Query executes a query on the database
+	// Describe queries the columns, keys and indexes of table
+	Describe(table Table) (*TableStructure, error)
+
+	// DDL returns the statements that create table
+	DDL(table Table) (string, error)
+
+	// Query executes a query on the database and returns a result for every result set it produced
+	// Only the first PageSize rows are fetched, the rest can be loaded using FetchMore
+	// Only the last result can have more rows, a result set with more than PageSize rows
+	// ends the results and any result sets after it are skipped
+	// args are bound to the placeholders of the query
 	// Returns an error if the query failed or was cancelled
-	Query(query Query) (*QueryResult, error)
+	Query(query Query, args ...any) ([]*QueryResult, error)
+
+	// Messages returns the notices and warnings the server sent during the last Query or Exec
+	Messages() []Message
+
+	// Progress reports how long the running query has taken and how many rows it fetched,
+	// it is safe to call while Query or Exec is running
+	Progress() Progress
+
+	// FetchMore fetches the next page of rows of result
+	// Only the result of the latest Query can be fetched from,
+	// starting a new query closes the rows of the previous one
+	// hasMore is false once all rows were fetched, truncated is true when the rows were closed
+	// before all of them were fetched, in which case no more rows can be fetched either
+	FetchMore(result *QueryResult) (rows [][]Cell, hasMore, truncated bool, err error)
+
+	// Explain returns the execution plan of query
+	// When analyze is true the query is executed to measure the actual rows and time
+	Explain(query Query, analyze bool) (*Plan, error)
+
+	// Exec executes a statement that does not return rows, like an UPDATE or DDL
+	// args are bound to the placeholders of the statement
+	// Returns an error if the statement failed or was cancelled
+	Exec(query Query, args ...any) (*ExecResult, error)
+
+	// CancelQuery cancels any running query, also on the server when the driver supports it
+	// Returns an error if asking the server to cancel the statement failed
+	CancelQuery() (CancelOutcome, error)
+
+	// WithoutTimeout makes the next query run without the query timeout,
+	// for queries that are deliberately slow
+	WithoutTimeout()
+
+	// Begin opens a transaction on a dedicated connection
+	// Queries are executed inside the transaction until Commit or Rollback is called
+	Begin() error
 
-	// CancelQuery cancels any running query
-	// Returns true if a query was cancelled
-	CancelQuery() bool
+	// Commit commits the open transaction
+	Commit() error
+
+	// Rollback rolls back the open transaction
+	Rollback() error
+
+	// Transaction reports whether a transaction is open
+	// and how many statements have been executed in it
+	Transaction() (open bool, statements int)
 
 	// Closes the database and cancels any running queries
 	Close() error
@@ -59,9 +192,67 @@ type Driver interface {
 type BaseDriver struct {
 	currentQuery Query
 	Db           *sql.DB
-	context      context.Context
-	cancelFunc   context.CancelFunc
-	queryMutex   sync.Mutex
+	// ColumnKind classifies a column by the type name the database reports for it
+	// When nil every column is treated as text
+	ColumnKind func(databaseType string) ColumnKind
+	// ConvertValue converts a value the database returns in a raw form, like bytes for a number,
+	// before it is formatted as a cell
+	// When nil values are formatted as they were scanned
+	ConvertValue func(value any, column Column) any
+	// ExecConn executes a statement on conn for drivers that can report more than database/sql
+	// When nil the statement is executed using conn.ExecContext
+	ExecConn func(ctx context.Context, conn *sql.Conn, query Query, args []any) (*ExecResult, error)
+	// Dialer is closed after the database when set
+	Dialer Dialer
+	// QueryTimeout cancels queries that run longer, 0 disables the timeout
+	QueryTimeout time.Duration
+	// ServerTimeout sets the statement timeout of the server on conn, 0 disables it
+	// When nil queries are only cancelled by the client
+	ServerTimeout func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error
+	// IsTimeout reports whether err is the server ending a statement because of its timeout
+	IsTimeout func(err error) bool
+	// ConnectionID returns the id the server knows the session of conn by
+	ConnectionID func(ctx context.Context, conn *sql.Conn) (int64, error)
+	// CancelOnServer cancels the statement running in the session with connectionID using another connection of db
+	// Returns whether the server confirmed the cancel
+	// When nil queries are only cancelled by the client
+	CancelOnServer func(ctx context.Context, db *sql.DB, connectionID int64) (bool, error)
+
+	context    context.Context
+	cancelFunc context.CancelFunc
+	queryMutex sync.Mutex
+	open       *openResult
+	// transaction is the connection of the open transaction, nil when no transaction is open
+	transaction  *sql.Conn
+	txStatements int
+	// skipTimeout disables the query timeout for the next query
+	skipTimeout bool
+	// connectionID is the server session running the current query, 0 when unknown
+	connectionID int64
+	// progress tracks the current query, or the last one when none is running
+	progress *queryProgress
+	// Warnings fetches the warnings of the statement that was last executed on conn,
+	// for databases that only report them when asked
+	// When nil only the messages passed to AddMessage are reported
+	Warnings func(ctx context.Context, conn *sql.Conn) ([]Message, error)
+
+	messagesMutex sync.Mutex
+	// messages are the notices and warnings of the current query
+	messages []Message
+}
+
+// openResult holds on to the rows of a result that has not been fetched completely
+type openResult struct {
+	result  *QueryResult
+	rows    *sql.Rows
+	context context.Context
+	cancel  context.CancelFunc
+	// conn is the connection the rows are fetched from
+	conn *sql.Conn
+	// close releases the rows, the connection and the context
+	close func()
+	// connectionID is the server session the rows are fetched from, 0 when unknown
+	connectionID int64
 }
 
 func (b *BaseDriver) CurrentQuery() Query {
@@ -75,81 +266,287 @@ func DatabaseNames(databases []Database) []string {
 
 func (b *BaseDriver) Close() error {
 	b.CancelQuery()
-	return b.Db.Close()
+	b.queryMutex.Lock()
+	b.closeOpenResult()
+	b.queryMutex.Unlock()
+	if open, _ := b.Transaction(); open {
+		b.Rollback()
+	}
+	err := b.Db.Close()
+	if b.Dialer != nil {
+		err = errors.Join(err, b.Dialer.Close())
+	}
+	return err
 }
 
-func (b *BaseDriver) CancelQuery() bool {
-	b.queryMutex.Lock()
-	defer b.queryMutex.Unlock()
-	if b.cancelFunc != nil {
-		b.cancelFunc()
-		return true
-	} else {
-		return false
+// closeOpenResult closes the rows of a partially fetched result
+// queryMutex should be held by the caller
+func (b *BaseDriver) closeOpenResult() {
+	if b.open == nil {
+		return
 	}
+	b.open.close()
+	b.open = nil
 }
 
-func (b *BaseDriver) Query(query Query) (*QueryResult, error) {
+// startQuery cancels any running query and closes open results
+// before making query the running query
+// The returned timeout has to be stopped and the progress finished once the query returned
+func (b *BaseDriver) startQuery(query Query) (context.Context, context.CancelFunc, *queryTimeout, *queryProgress) {
 	b.queryMutex.Lock()
-	if b.cancelFunc != nil {
-		b.cancelFunc()
-	}
+	defer b.queryMutex.Unlock()
+	b.interruptQuery()
+	b.closeOpenResult()
 	context, cancel := context.WithCancel(context.Background())
 	b.context = context
 	b.cancelFunc = cancel
+	b.connectionID = 0
 	b.currentQuery = query
-	b.queryMutex.Unlock()
-	defer func() {
-		b.queryMutex.Lock()
-		defer b.queryMutex.Unlock()
-		if b.context == context {
-			b.context = nil
-			b.cancelFunc = nil
-		}
-	}()
+	b.progress = newQueryProgress()
+	b.clearMessages()
+	return context, cancel, b.newQueryTimeout(cancel), b.progress
+}
+
+func (b *BaseDriver) Exec(query Query, args ...any) (*ExecResult, error) {
+	context, cancel, timeout, progress := b.startQuery(query)
+	defer cancel()
+	defer timeout.stop()
+	defer b.finishQuery(context)
+	defer progress.finish()
+
+	conn, release, _, err := b.conn(context)
+	if err != nil {
+		return nil, timeout.err(err)
+	}
+	defer release()
+	b.trackConnection(context, conn)
+	if err := b.setServerTimeout(context, conn, timeout); err != nil {
+		return nil, timeout.err(err)
+	}
 
-	data := [][]string{}
-	rows, err := b.Db.QueryContext(context, string(query))
+	if b.ExecConn != nil {
+		result, err := b.ExecConn(context, conn, query, args)
+		if err != nil {
+			return nil, b.checkTransaction(conn, timeout.err(err))
+		}
+		progress.finish()
+		b.collectWarnings(context, conn)
+		result.Duration = progress.progress().Elapsed
+		return result, nil
+	}
+	result, err := conn.ExecContext(context, string(query), args...)
+	if err != nil {
+		return nil, b.checkTransaction(conn, timeout.err(err))
+	}
+	execResult := &ExecResult{}
+	execResult.RowsAffected, err = result.RowsAffected()
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	switch query.Keyword() {
+	case "INSERT", "REPLACE":
+		if id, err := result.LastInsertId(); err == nil && id != 0 {
+			execResult.LastInsertId = id
+			execResult.HasLastInsertId = true
+		}
+	}
+	progress.finish()
+	execResult.Duration = progress.progress().Elapsed
+	b.collectWarnings(context, conn)
+	return execResult, nil
+}
 
-	index := 0
-	columns, err := rows.Columns()
-	numColumns := len(columns)
+func (b *BaseDriver) Query(query Query, args ...any) ([]*QueryResult, error) {
+	context, cancel, timeout, progress := b.startQuery(query)
+	defer timeout.stop()
+	defer b.finishQuery(context)
+	defer progress.finish()
+
+	conn, release, inTransaction, err := b.conn(context)
+	if err != nil {
+		cancel()
+		return nil, timeout.err(err)
+	}
+	connectionID := b.trackConnection(context, conn)
+	if err := b.setServerTimeout(context, conn, timeout); err != nil {
+		release()
+		cancel()
+		return nil, timeout.err(err)
+	}
+	rows, err := conn.QueryContext(context, string(query), args...)
+	if err != nil {
+		release()
+		cancel()
+		return nil, b.checkTransaction(conn, timeout.err(err))
+	}
+	closeRows := func() {
+		if inTransaction {
+			// Cancelling first can make drivers close the connection
+			// which would lose the transaction
+			rows.Close()
+			cancel()
+		} else {
+			cancel()
+			rows.Close()
+			release()
+		}
+	}
+
+	results := []*QueryResult{}
+	var result *QueryResult
+	for {
+		result, err = b.readResultSet(context, rows, progress)
+		if err != nil {
+			closeRows()
+			return nil, b.checkTransaction(conn, timeout.err(err))
+		}
+		if len(result.Columns) > 0 {
+			results = append(results, result)
+		}
+		if result.HasMore || !rows.NextResultSet() {
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		closeRows()
+		return nil, b.checkTransaction(conn, timeout.err(err))
+	}
+	if len(results) == 0 {
+		results = append(results, result)
+	}
+	progress.finish()
+	elapsed := progress.progress().Elapsed
+	for _, result := range results {
+		result.Duration = elapsed
+	}
+	if !result.HasMore {
+		// The connection can only be asked for warnings once the rows are closed,
+		// the warnings of a result that has more rows are not collected
+		rows.Close()
+		b.collectWarnings(context, conn)
+		closeRows()
+		return results, nil
+	}
+
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.context != context {
+		// A newer query was started while fetching the first page
+		closeRows()
+		return nil, timeout.err(context.Err())
+	}
+	b.open = &openResult{
+		result:       result,
+		rows:         rows,
+		conn:         conn,
+		context:      context,
+		cancel:       cancel,
+		close:        closeRows,
+		connectionID: connectionID,
+	}
+	return results, nil
+}
+
+// readResultSet reads the columns and the first page of rows of the current result set of rows
+func (b *BaseDriver) readResultSet(context context.Context, rows *sql.Rows, progress *queryProgress) (*QueryResult, error) {
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{
+		Columns: make([]Column, len(columnTypes)),
+	}
+	for i, columnType := range columnTypes {
+		result.Columns[i] = b.column(columnType)
+	}
+	result.Rows, result.HasMore, err = b.fetch(context, rows, result.Columns, progress)
 	if err != nil {
 		return nil, err
 	}
-	for rows.Next() && index < 9999 {
-		if context.Err() != nil {
-			return nil, context.Err()
+	return result, nil
+}
+
+func (b *BaseDriver) FetchMore(result *QueryResult) ([][]Cell, bool, bool, error) {
+	b.queryMutex.Lock()
+	open := b.open
+	if open == nil || open.result != result {
+		b.queryMutex.Unlock()
+		return nil, false, true, ErrResultClosed
+	}
+	b.context = open.context
+	b.cancelFunc = open.cancel
+	b.connectionID = open.connectionID
+	b.queryMutex.Unlock()
+	defer b.finishQuery(open.context)
+
+	rows, hasMore, err := b.fetch(open.context, open.rows, result.Columns, nil)
+
+	b.queryMutex.Lock()
+	if err != nil || !hasMore {
+		if b.open == open {
+			b.closeOpenResult()
 		}
-		row := make([]sql.NullString, numColumns)
-		scannableRow := make([]interface{}, numColumns)
+		b.queryMutex.Unlock()
+		return rows, false, err != nil, b.checkTransaction(open.conn, err)
+	}
+	b.queryMutex.Unlock()
+	return rows, true, false, nil
+}
+
+// finishQuery clears the running query if it has not been replaced by another query yet
+func (b *BaseDriver) finishQuery(context context.Context) {
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.context == context {
+		b.context = nil
+		b.cancelFunc = nil
+		b.connectionID = 0
+	}
+}
+
+// fetch scans at most PageSize rows
+// hasMore is true when the page was filled, meaning there might be more rows
+// The scanned rows are counted in progress when it is not nil
+func (b *BaseDriver) fetch(context context.Context, rows *sql.Rows, columns []Column, progress *queryProgress) (data [][]Cell, hasMore bool, err error) {
+	numColumns := len(columns)
+	data = [][]Cell{}
+	for len(data) < PageSize {
+		if !rows.Next() {
+			if context.Err() != nil {
+				return nil, false, context.Err()
+			}
+			return data, false, rows.Err()
+		}
+		row := make([]any, numColumns)
+		scannableRow := make([]any, numColumns)
 		for i := range row {
 			scannableRow[i] = &row[i]
 		}
-		err := rows.Scan(scannableRow...)
-		rowValues := make([]string, numColumns)
-		for i, column := range row {
-			if column.Valid {
-				rowValues[i] = strings.ReplaceAll(column.String, "\r", "")
-			} else {
-				rowValues[i] = "NULL"
-			}
+		if err := rows.Scan(scannableRow...); err != nil {
+			return nil, false, err
 		}
-		data = append(data, rowValues)
-		if err != nil {
-			return nil, err
+		cells := make([]Cell, numColumns)
+		for i, value := range row {
+			if b.ConvertValue != nil {
+				value = b.ConvertValue(value, columns[i])
+			}
+			cells[i] = formatCell(value, columns[i])
 		}
-		index += 1
+		data = append(data, cells)
+		progress.addRows(1)
+	}
+	return data, true, nil
+}
+
+func (b *BaseDriver) column(columnType *sql.ColumnType) Column {
+	column := Column{
+		Name:         columnType.Name(),
+		DatabaseType: columnType.DatabaseTypeName(),
+		ScanType:     columnType.ScanType(),
 	}
-	if context.Err() != nil {
-		return nil, context.Err()
+	column.Nullable, column.HasNullable = columnType.Nullable()
+	if b.ColumnKind != nil {
+		column.Kind = b.ColumnKind(column.DatabaseType)
 	}
-	return &QueryResult{
-		Columns: columns,
-		Data:    data,
-	}, nil
+	return column
 }
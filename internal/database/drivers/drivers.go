@@ -0,0 +1,9 @@
+// Package drivers registers all database drivers,
+// import it for its side effects to be able to connect to every kind of database
+package drivers
+
+import (
+	_ "github.com/Kavantix/lazysql/internal/database/drivers/mysqldriver"
+	_ "github.com/Kavantix/lazysql/internal/database/drivers/pgxdriver"
+	_ "github.com/Kavantix/lazysql/internal/database/drivers/sqlitedriver"
+)
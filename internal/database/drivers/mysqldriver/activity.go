@@ -0,0 +1,96 @@
+package mysqldriver
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+var _ database.ActivityMonitor = &mysqlDriver{}
+
+// Sessions implements database.ActivityMonitor.
+func (m *mysqlDriver) Sessions() ([]database.Session, error) {
+	ctx := context.Background()
+	conn, err := m.Db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// The processlist is read on a known session to leave it out
+	ownID, err := connectionID(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := conn.QueryContext(ctx, "SHOW FULL PROCESSLIST")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	// MariaDB adds columns, so they are read by name
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	sessions := []database.Session{}
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		scannable := make([]any, len(columns))
+		for i := range values {
+			scannable[i] = &values[i]
+		}
+		if err := rows.Scan(scannable...); err != nil {
+			return nil, err
+		}
+		row := map[string]sql.NullString{}
+		for i, column := range columns {
+			row[strings.ToLower(column)] = values[i]
+		}
+		session, err := processlistSession(row)
+		if err != nil {
+			return nil, err
+		}
+		if session.ID != ownID {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, rows.Err()
+}
+
+// processlistSession converts a row of SHOW FULL PROCESSLIST, keyed by the lowercase column names
+// The command is used as the state and the state as what the session waits for
+func processlistSession(row map[string]sql.NullString) (database.Session, error) {
+	session := database.Session{
+		User:      row["user"].String,
+		Database:  row["db"].String,
+		State:     row["command"].String,
+		WaitEvent: row["state"].String,
+		Query:     row["info"].String,
+	}
+	if _, err := fmt.Sscan(row["id"].String, &session.ID); err != nil {
+		return session, fmt.Errorf("invalid process id %q", row["id"].String)
+	}
+	if seconds := row["time"]; seconds.Valid {
+		var amount int64
+		if _, err := fmt.Sscan(seconds.String, &amount); err == nil {
+			session.Duration = time.Duration(amount) * time.Second
+			session.HasDuration = true
+		}
+	}
+	return session, nil
+}
+
+// CancelSession implements database.ActivityMonitor.
+func (m *mysqlDriver) CancelSession(id int64) error {
+	_, err := m.Db.Exec(fmt.Sprintf("KILL QUERY %d", id))
+	return err
+}
+
+// TerminateSession implements database.ActivityMonitor.
+func (m *mysqlDriver) TerminateSession(id int64) error {
+	_, err := m.Db.Exec(fmt.Sprintf("KILL %d", id))
+	return err
+}
@@ -0,0 +1,47 @@
+package mysqldriver
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestProcesslistSession(t *testing.T) {
+	text := func(value string) sql.NullString {
+		return sql.NullString{String: value, Valid: true}
+	}
+	session, err := processlistSession(map[string]sql.NullString{
+		"id":      text("42"),
+		"user":    text("app"),
+		"db":      text("shop"),
+		"command": text("Query"),
+		"time":    text("75"),
+		"state":   text("Waiting for table metadata lock"),
+		"info":    text("ALTER TABLE orders ADD COLUMN note TEXT"),
+	})
+	if err != nil {
+		t.Fatalf("Failed to convert row: %s", err)
+	}
+	if session.ID != 42 || session.User != "app" || session.Database != "shop" || session.State != "Query" {
+		t.Fatalf("Incorrect session %#v", session)
+	}
+	if !session.HasDuration || session.Duration != 75*time.Second {
+		t.Fatalf("Incorrect duration %s", session.Duration)
+	}
+	if session.WaitEvent != "Waiting for table metadata lock" || session.Query != "ALTER TABLE orders ADD COLUMN note TEXT" {
+		t.Fatalf("Incorrect wait event or query %#v", session)
+	}
+
+	// Sleeping sessions have no statement and MariaDB leaves the time of some sessions empty
+	session, err = processlistSession(map[string]sql.NullString{
+		"id":      text("7"),
+		"command": text("Sleep"),
+	})
+	if err != nil || session.HasDuration || session.Query != "" {
+		t.Fatalf("Incorrect idle session %#v, %v", session, err)
+	}
+
+	if _, err := processlistSession(map[string]sql.NullString{"id": text("abc")}); err == nil {
+		t.Fatalf("Expected an error for an invalid id")
+	}
+}
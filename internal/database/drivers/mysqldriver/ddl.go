@@ -0,0 +1,58 @@
+package mysqldriver
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// DDL implements Driver.
+func (m *mysqlDriver) DDL(dbTable database.Table) (string, error) {
+	table := dbTable.(mysqlTable)
+	statement := map[database.ObjectKind]string{
+		database.ObjectTable:     "TABLE",
+		database.ObjectView:      "VIEW",
+		database.ObjectSequence:  "SEQUENCE",
+		database.ObjectFunction:  "FUNCTION",
+		database.ObjectProcedure: "PROCEDURE",
+	}[table.kind]
+	if statement == "" {
+		return "", fmt.Errorf("cannot show the DDL of a %s", table.kind)
+	}
+	rows, err := m.Db.Query(fmt.Sprintf("SHOW CREATE %s %s", statement, quoteIdentifier(table.name)))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return "", err
+		}
+		return "", errors.New("no DDL returned")
+	}
+	values := make([]sql.NullString, len(columns))
+	scannable := make([]any, len(columns))
+	for i := range values {
+		scannable[i] = &values[i]
+	}
+	if err := rows.Scan(scannable...); err != nil {
+		return "", err
+	}
+	// The statement is in the column named after it, e.g. `Create Table`
+	for i, column := range columns {
+		if strings.HasPrefix(column, "Create ") {
+			if !values[i].Valid {
+				return "", errors.New("not allowed to see the DDL")
+			}
+			return values[i].String + ";", nil
+		}
+	}
+	return "", errors.New("no DDL returned")
+}
@@ -1,23 +1,44 @@
 package mysqldriver
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+	"net"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/Kavantix/lazysql/internal/credentials"
 	"github.com/Kavantix/lazysql/internal/database"
 	"github.com/go-sql-driver/mysql"
 )
 
 var _ database.Driver = &mysqlDriver{}
 
+const defaultPort = 3306
+
+func init() {
+	database.Register(database.DriverInfo{
+		Name:        "mysql",
+		DefaultPort: defaultPort,
+		Fields:      []database.Field{database.FieldHost, database.FieldPort, database.FieldUser, database.FieldPassword},
+		Credentials: credentials.Mysql,
+		New:         NewMysqlDriver,
+	})
+}
+
 type mysqlDriver struct {
 	database.BaseDriver
 	config *mysql.Config
 }
 
-type mysqlTable string
+type mysqlTable struct {
+	name string
+	kind database.ObjectKind
+}
 
 // EqualsTable implements database.Table.
 func (t mysqlTable) EqualsTable(other database.Table) bool {
@@ -26,24 +47,51 @@ func (t mysqlTable) EqualsTable(other database.Table) bool {
 }
 
 func (t mysqlTable) DisplayString() string {
-	return string(t)
+	return t.name
+}
+
+// Kind implements database.Table.
+func (t mysqlTable) Kind() database.ObjectKind {
+	return t.kind
+}
+
+// Dialect implements Driver.
+func (m *mysqlDriver) Dialect() database.Dialect {
+	return database.DialectMysql
 }
 
 // QueryForTable implements Driver.
-func (m *mysqlDriver) QueryForTable(table database.Table, limit int) database.Query {
-	return database.Query(fmt.Sprintf("SELECT *\nFROM `%s`\nLIMIT %d", table, limit))
+func (m *mysqlDriver) QueryForTable(dbTable database.Table) database.Query {
+	table := dbTable.(mysqlTable)
+	switch table.kind {
+	case database.ObjectFunction:
+		return database.Query("SHOW CREATE FUNCTION " + quoteIdentifier(table.name))
+	case database.ObjectProcedure:
+		return database.Query("SHOW CREATE PROCEDURE " + quoteIdentifier(table.name))
+	default:
+		return database.Query("SELECT *\nFROM " + quoteIdentifier(table.name))
+	}
+}
+
+func quoteIdentifier(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
 }
 
 func NewMysqlDriver(dsn database.Dsn) (database.Driver, error) {
 	config := mysql.NewConfig()
 	port := dsn.Port
 	if port == 0 {
-		port = 3306
+		port = defaultPort
 	}
-	config.Addr = dsn.Host + ":" + strconv.FormatInt(int64(dsn.Port), 10)
+	config.Addr = net.JoinHostPort(dsn.Host, strconv.Itoa(int(port)))
 	config.User = dsn.User
 	config.Passwd = dsn.Password
-	config.TLSConfig = "skip-verify"
+	if err := applyTLS(config, dsn); err != nil {
+		return nil, err
+	}
+	if dsn.Dialer != nil {
+		config.Net = registerDialer(dsn.Dialer)
+	}
 
 	connector, err := mysql.NewConnector(config)
 	if err != nil {
@@ -53,13 +101,144 @@ func NewMysqlDriver(dsn database.Dsn) (database.Driver, error) {
 	driver := &mysqlDriver{
 		config: config,
 		BaseDriver: database.BaseDriver{
-			Db: sql.OpenDB(connector),
+			Db:             sql.OpenDB(connector),
+			ColumnKind:     columnKind,
+			ConvertValue:   convertValue,
+			Dialer:         dsn.Dialer,
+			QueryTimeout:   dsn.QueryTimeout,
+			ServerTimeout:  serverTimeout,
+			IsTimeout:      isTimeout,
+			ConnectionID:   connectionID,
+			CancelOnServer: cancelOnServer,
+			Warnings:       warnings,
 		},
 	}
 
 	return driver, nil
 }
 
+// warnings returns the warnings of the last statement on conn,
+// like the values that were silently truncated by an insert
+func warnings(ctx context.Context, conn *sql.Conn) ([]database.Message, error) {
+	rows, err := conn.QueryContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	messages := []database.Message{}
+	for rows.Next() {
+		var message database.Message
+		if err := rows.Scan(&message.Severity, &message.Code, &message.Text); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+var dialers, tlsConfigs atomic.Int64
+
+// applyTLS sets the TLS config of config for the mode of dsn
+// The preferred mode of the mysql driver is used when no certificates are configured,
+// otherwise TLS is required since a registered config cannot fall back to plain connections
+func applyTLS(config *mysql.Config, dsn database.Dsn) error {
+	switch {
+	case dsn.TLS.Mode == database.TLSDisable:
+		config.TLSConfig = "false"
+		return nil
+	case (dsn.TLS.Mode == database.TLSDefault || dsn.TLS.Mode == database.TLSPrefer) && !dsn.TLS.HasFiles():
+		config.TLSConfig = "preferred"
+		return nil
+	}
+	tlsConfig, err := dsn.TLS.Config(dsn.Host)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("lazysql-tls-%d", tlsConfigs.Add(1))
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return err
+	}
+	config.TLSConfig = name
+	return nil
+}
+
+// registerDialer registers dialer as a network and returns its name,
+// the mysql driver only supports custom dialers by network name
+func registerDialer(dialer database.Dialer) string {
+	name := fmt.Sprintf("lazysql-dialer-%d", dialers.Add(1))
+	mysql.RegisterDialContext(name, func(ctx context.Context, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", addr)
+	})
+	return name
+}
+
+const (
+	errUnknownSystemVariable = 1193
+	// errMaxExecutionTime is returned by MySQL when max_execution_time is exceeded
+	errMaxExecutionTime = 3024
+	// errMaxStatementTime is returned by MariaDB when max_statement_time is exceeded
+	errMaxStatementTime = 1969
+)
+
+// serverTimeout sets the maximum execution time of statements in the session of conn
+// MySQL only applies it to SELECT statements, MariaDB calls it max_statement_time
+func serverTimeout(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_execution_time = %d", timeout.Milliseconds()))
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errUnknownSystemVariable {
+		_, err = conn.ExecContext(ctx, fmt.Sprintf("SET SESSION max_statement_time = %g", timeout.Seconds()))
+	}
+	return err
+}
+
+// isTimeout reports whether err is a statement interrupted because of its maximum execution time
+func isTimeout(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && (mysqlErr.Number == errMaxExecutionTime || mysqlErr.Number == errMaxStatementTime)
+}
+
+// connectionID returns the id of the session of conn
+func connectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var id int64
+	err := conn.QueryRowContext(ctx, "SELECT CONNECTION_ID()").Scan(&id)
+	return id, err
+}
+
+// cancelOnServer kills the statement of the session with id, the session itself is kept
+func cancelOnServer(ctx context.Context, db *sql.DB, id int64) (bool, error) {
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL QUERY %d", id)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func columnKind(databaseType string) database.ColumnKind {
+	switch databaseType {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "BIGINT",
+		"DECIMAL", "FLOAT", "DOUBLE", "YEAR", "BIT":
+		return database.KindNumber
+	case "DATE", "DATETIME", "TIMESTAMP", "TIME":
+		return database.KindTime
+	case "BINARY", "VARBINARY", "TINYBLOB", "BLOB", "MEDIUMBLOB", "LONGBLOB", "GEOMETRY":
+		return database.KindBinary
+	default:
+		return database.KindText
+	}
+}
+
+// convertValue decodes BIT values, which are sent as big-endian bytes, to numbers
+func convertValue(value any, column database.Column) any {
+	bits, ok := value.([]byte)
+	if !ok || column.DatabaseType != "BIT" || len(bits) > 8 {
+		return value
+	}
+	var number uint64
+	for _, b := range bits {
+		number = number<<8 | uint64(b)
+	}
+	return number
+}
+
 func (m *mysqlDriver) Databases() ([]database.Database, error) {
 	databases := []database.Database{}
 	rows, err := m.Db.Query("SHOW DATABASES")
@@ -83,6 +262,9 @@ func (m *mysqlDriver) SelectDatabase(db database.Database) error {
 	if m.config.DBName == string(db) {
 		return nil
 	}
+	if open, _ := m.Transaction(); open {
+		return errors.New("cannot change database while a transaction is open")
+	}
 	if m.Db != nil {
 		go m.Db.Close()
 		m.config.DBName = string(db)
@@ -100,18 +282,51 @@ func (m *mysqlDriver) Tables() ([]database.Table, error) {
 		return nil, errors.New("no database selected")
 	}
 	tables := []database.Table{}
-	rows, err := m.Db.Query("SHOW TABLES")
+	rows, err := m.Db.Query("SHOW FULL TABLES")
 	if err != nil {
 		return tables, err
 	}
-	index := 0
+	defer rows.Close()
 	for rows.Next() {
-		tables = append(tables, mysqlTable(""))
-		err := rows.Scan(&tables[index])
+		table := mysqlTable{}
+		var tableType string
+		err := rows.Scan(&table.name, &tableType)
 		if err != nil {
 			return tables, err
 		}
-		index += 1
+		switch tableType {
+		case "VIEW", "SYSTEM VIEW":
+			table.kind = database.ObjectView
+		case "SEQUENCE":
+			table.kind = database.ObjectSequence
+		default:
+			table.kind = database.ObjectTable
+		}
+		tables = append(tables, table)
+	}
+	if err := rows.Err(); err != nil {
+		return tables, err
+	}
+
+	routines, err := m.Db.Query(`
+		SELECT ROUTINE_NAME, ROUTINE_TYPE
+		FROM information_schema.ROUTINES
+		WHERE ROUTINE_SCHEMA = ?
+		ORDER BY ROUTINE_NAME`, m.config.DBName)
+	if err != nil {
+		return tables, err
+	}
+	defer routines.Close()
+	for routines.Next() {
+		routine := mysqlTable{kind: database.ObjectFunction}
+		var routineType string
+		if err := routines.Scan(&routine.name, &routineType); err != nil {
+			return tables, err
+		}
+		if routineType == "PROCEDURE" {
+			routine.kind = database.ObjectProcedure
+		}
+		tables = append(tables, routine)
 	}
-	return tables, nil
+	return tables, routines.Err()
 }
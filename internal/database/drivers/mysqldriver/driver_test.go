@@ -0,0 +1,79 @@
+package mysqldriver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestApplyTLS(t *testing.T) {
+	tests := []struct {
+		tls      database.TLS
+		expected string
+	}{
+		{database.TLS{}, "preferred"},
+		{database.TLS{Mode: database.TLSPrefer}, "preferred"},
+		{database.TLS{Mode: database.TLSDisable}, "false"},
+		{database.TLS{Mode: database.TLSRequire}, "lazysql-tls-"},
+		{database.TLS{Mode: database.TLSVerifyFull, ServerName: "db.example.com"}, "lazysql-tls-"},
+	}
+	for _, test := range tests {
+		config := mysql.NewConfig()
+		if err := applyTLS(config, database.Dsn{Host: "db.internal", TLS: test.tls}); err != nil {
+			t.Fatalf("Failed to apply %#v: %s", test.tls, err)
+		}
+		if !strings.HasPrefix(config.TLSConfig, test.expected) {
+			t.Fatalf("Incorrect TLS config %s for %#v", config.TLSConfig, test.tls)
+		}
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	if !isTimeout(&mysql.MySQLError{Number: errMaxExecutionTime}) || !isTimeout(&mysql.MySQLError{Number: errMaxStatementTime}) {
+		t.Fatalf("Exceeding the maximum execution time should be a timeout")
+	}
+	if isTimeout(&mysql.MySQLError{Number: 1317, Message: "Query execution was interrupted"}) {
+		t.Fatalf("A killed query is not a timeout")
+	}
+}
+
+func TestColumnKind(t *testing.T) {
+	tests := []struct {
+		databaseType string
+		expected     database.ColumnKind
+	}{
+		{"INT", database.KindNumber},
+		{"BIT", database.KindNumber},
+		{"DATETIME", database.KindTime},
+		{"VARBINARY", database.KindBinary},
+		{"VARCHAR", database.KindText},
+	}
+	for _, test := range tests {
+		if kind := columnKind(test.databaseType); kind != test.expected {
+			t.Fatalf("Expected kind %d for %s, got %d", test.expected, test.databaseType, kind)
+		}
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	bit := database.Column{DatabaseType: "BIT", Kind: database.KindNumber}
+	tests := []struct {
+		value    any
+		column   database.Column
+		expected any
+	}{
+		{[]byte{0x01}, bit, uint64(1)},
+		{[]byte{0x01, 0x02}, bit, uint64(258)},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, bit, uint64(18446744073709551615)},
+		{nil, bit, nil},
+		{[]byte("12"), database.Column{DatabaseType: "INT", Kind: database.KindNumber}, []byte("12")},
+	}
+	for _, test := range tests {
+		if converted := convertValue(test.value, test.column); !reflect.DeepEqual(converted, test.expected) {
+			t.Fatalf("Expected %#v for %#v, got %#v", test.expected, test.value, converted)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package mysqldriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// Explain implements Driver.
+// Analyzed plans use EXPLAIN ANALYZE which only supports the tree format
+func (m *mysqlDriver) Explain(query database.Query, analyze bool) (*database.Plan, error) {
+	explain := "EXPLAIN FORMAT=JSON "
+	if analyze {
+		explain = "EXPLAIN ANALYZE "
+	}
+	results, err := m.Query(database.Query(explain + string(query)))
+	if err != nil {
+		return nil, err
+	}
+	value, err := database.FirstValue(results)
+	if err != nil {
+		return nil, err
+	}
+	var root *database.PlanNode
+	if analyze {
+		root, err = parseTreePlan(value)
+	} else {
+		root, err = parseJsonPlan([]byte(value))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &database.Plan{Root: root, Analyzed: analyze}, nil
+}
+
+// parseJsonPlan parses the output of EXPLAIN FORMAT=JSON
+// Every object in the plan becomes a node named after its key
+func parseJsonPlan(data []byte) (*database.PlanNode, error) {
+	plan := map[string]any{}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	block, ok := plan["query_block"].(map[string]any)
+	if !ok {
+		return nil, errors.New("failed to parse plan: no query block")
+	}
+	return jsonPlanNode("query_block", block), nil
+}
+
+func jsonPlanNode(key string, object map[string]any) *database.PlanNode {
+	node := &database.PlanNode{
+		Operation: humanize(key),
+	}
+	details := []string{}
+	if key == "table" {
+		details = append(details, fmt.Sprint(object["table_name"]))
+		if accessType, ok := object["access_type"].(string); ok {
+			details = append(details, accessType)
+		}
+		if index, ok := object["key"].(string); ok {
+			details = append(details, "using "+index)
+		}
+	}
+	if rows, ok := object["rows_examined_per_scan"].(float64); ok {
+		node.EstimatedRows = rows
+		node.HasEstimate = true
+	}
+	if costInfo, ok := object["cost_info"].(map[string]any); ok {
+		// Tables report a prefix cost that includes the tables joined before them,
+		// so their own read and eval costs are used instead
+		if cost, ok := jsonCost(costInfo, "query_cost"); ok {
+			node.Cost = cost
+		} else {
+			readCost, _ := jsonCost(costInfo, "read_cost")
+			evalCost, _ := jsonCost(costInfo, "eval_cost")
+			node.Cost = readCost + evalCost
+		}
+		node.HasEstimate = true
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch value := object[key].(type) {
+		case map[string]any:
+			if key != "cost_info" {
+				node.Children = append(node.Children, jsonPlanNode(key, value))
+			}
+		case []any:
+			if child := jsonPlanList(key, value); child != nil {
+				node.Children = append(node.Children, child)
+			}
+		case bool:
+			if value && strings.HasPrefix(key, "using_") {
+				details = append(details, humanize(key))
+			}
+		}
+	}
+	node.Detail = strings.Join(details, ", ")
+
+	if !node.HasEstimate && len(node.Children) > 0 {
+		for _, child := range node.Children {
+			node.Cost += child.Cost
+		}
+	}
+	return node
+}
+
+// jsonPlanList converts a list of objects, like the tables of a nested loop, to a node
+// Returns nil when the list does not contain objects, like the used columns of a table
+func jsonPlanList(key string, values []any) *database.PlanNode {
+	node := &database.PlanNode{
+		Operation: humanize(key),
+	}
+	for _, value := range values {
+		object, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if len(object) == 1 {
+			for key, value := range object {
+				if child, ok := value.(map[string]any); ok {
+					node.Children = append(node.Children, jsonPlanNode(key, child))
+				}
+			}
+		} else {
+			node.Children = append(node.Children, jsonPlanNode(key, object))
+		}
+	}
+	if len(node.Children) == 0 {
+		return nil
+	}
+	for _, child := range node.Children {
+		node.Cost += child.Cost
+	}
+	return node
+}
+
+// jsonCost parses a cost, which mysql formats as a string
+func jsonCost(costInfo map[string]any, key string) (float64, bool) {
+	value, ok := costInfo[key].(string)
+	if !ok {
+		return 0, false
+	}
+	cost, err := strconv.ParseFloat(value, 64)
+	return cost, err == nil
+}
+
+// humanize converts a json key like nested_loop to Nested loop
+func humanize(key string) string {
+	words := strings.ReplaceAll(key, "_", " ")
+	if words == "" {
+		return words
+	}
+	return strings.ToUpper(words[:1]) + words[1:]
+}
+
+var (
+	treeEstimate = regexp.MustCompile(`\(cost=([\d.e+]+) rows=([\d.e+]+)\)`)
+	treeActual   = regexp.MustCompile(`\(actual time=[\d.]+\.\.([\d.]+) rows=([\d.e+]+) loops=(\d+)\)`)
+)
+
+// parseTreePlan parses the output of EXPLAIN ANALYZE,
+// a tree of lines starting with -> that is indented by 4 spaces per level
+func parseTreePlan(plan string) (*database.PlanNode, error) {
+	// stack contains the last node of every depth
+	stack := []*database.PlanNode{}
+	var root *database.PlanNode
+	for _, line := range strings.Split(plan, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if !strings.HasPrefix(trimmed, "-> ") {
+			if len(stack) > 0 && strings.TrimSpace(line) != "" {
+				// Long descriptions continue on the next line
+				last := stack[len(stack)-1]
+				last.Detail = strings.TrimSpace(last.Detail + " " + strings.TrimSpace(line))
+			}
+			continue
+		}
+		depth := (len(line) - len(trimmed)) / 4
+		node := treePlanNode(strings.TrimPrefix(trimmed, "-> "))
+		if depth > len(stack) {
+			depth = len(stack)
+		}
+		stack = append(stack[:depth], node)
+		if depth == 0 {
+			if root != nil {
+				return nil, errors.New("failed to parse plan: more than one root")
+			}
+			root = node
+		} else {
+			parent := stack[depth-1]
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	if root == nil {
+		return nil, errors.New("failed to parse plan: no plan returned")
+	}
+	return root, nil
+}
+
+func treePlanNode(line string) *database.PlanNode {
+	node := &database.PlanNode{}
+	description := line
+	if index := strings.Index(description, "  ("); index >= 0 {
+		description = description[:index]
+	}
+	if match := treeEstimate.FindStringSubmatch(line); match != nil {
+		node.Cost, _ = strconv.ParseFloat(match[1], 64)
+		node.EstimatedRows, _ = strconv.ParseFloat(match[2], 64)
+		node.HasEstimate = true
+	}
+	if match := treeActual.FindStringSubmatch(line); match != nil {
+		// Actual values are averages per loop
+		time, _ := strconv.ParseFloat(match[1], 64)
+		rows, _ := strconv.ParseFloat(match[2], 64)
+		loops, _ := strconv.ParseFloat(match[3], 64)
+		node.Time = time * loops
+		node.ActualRows = rows * loops
+		node.HasActual = true
+	} else if strings.Contains(line, "(never executed)") {
+		node.HasActual = true
+	}
+	node.Operation, node.Detail = description, ""
+	for _, separator := range []string{": ", " on ", " using "} {
+		if index := strings.Index(description, separator); index >= 0 {
+			node.Operation = description[:index]
+			node.Detail = strings.TrimPrefix(description[index:], ": ")
+			node.Detail = strings.TrimSpace(node.Detail)
+			break
+		}
+	}
+	return node
+}
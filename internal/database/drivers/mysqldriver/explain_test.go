@@ -0,0 +1,82 @@
+package mysqldriver
+
+import (
+	"testing"
+)
+
+func TestParseJsonPlan(t *testing.T) {
+	root, err := parseJsonPlan([]byte(`{
+		"query_block": {
+			"select_id": 1,
+			"cost_info": {"query_cost": "12.50"},
+			"ordering_operation": {
+				"using_filesort": true,
+				"nested_loop": [
+					{"table": {"table_name": "posts", "access_type": "ALL", "rows_examined_per_scan": 100,
+						"cost_info": {"read_cost": "0.50", "eval_cost": "10.00", "prefix_cost": "10.50"},
+						"used_columns": ["id", "user_id"]}},
+					{"table": {"table_name": "users", "access_type": "eq_ref", "key": "PRIMARY", "rows_examined_per_scan": 1,
+						"cost_info": {"read_cost": "1.00", "eval_cost": "0.10", "prefix_cost": "11.60"}}}
+				]
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("Failed to parse plan: %s", err)
+	}
+	if root.Operation != "Query block" || root.Cost != 12.5 || len(root.Children) != 1 {
+		t.Fatalf("Incorrect root `%#v`", root)
+	}
+	ordering := root.Children[0]
+	if ordering.Operation != "Ordering operation" || ordering.Detail != "Using filesort" || len(ordering.Children) != 1 {
+		t.Fatalf("Incorrect ordering `%#v`", ordering)
+	}
+	loop := ordering.Children[0]
+	if loop.Operation != "Nested loop" || len(loop.Children) != 2 {
+		t.Fatalf("Incorrect nested loop `%#v`", loop)
+	}
+	posts, users := loop.Children[0], loop.Children[1]
+	if posts.Detail != "posts, ALL" || posts.EstimatedRows != 100 || posts.Cost != 10.5 || len(posts.Children) != 0 {
+		t.Fatalf("Incorrect posts table `%#v`", posts)
+	}
+	if users.Detail != "users, eq_ref, using PRIMARY" || users.Cost < 1.09 || users.Cost > 1.11 {
+		t.Fatalf("Incorrect users table `%#v`", users)
+	}
+
+	if _, err := parseJsonPlan([]byte(`{}`)); err == nil {
+		t.Fatalf("Expected an error for a plan without query block")
+	}
+}
+
+func TestParseTreePlan(t *testing.T) {
+	root, err := parseTreePlan(`-> Nested loop inner join  (cost=4.95 rows=10) (actual time=0.050..0.100 rows=10 loops=1)
+    -> Filter: (p.published = 1)  (cost=1.25 rows=10) (actual time=0.030..0.050 rows=10 loops=1)
+        -> Table scan on p  (cost=1.25 rows=100) (actual time=0.020..0.040 rows=100 loops=1)
+    -> Single-row index lookup on u using PRIMARY (id=p.user_id)  (cost=0.26 rows=1) (actual time=0.003..0.004 rows=1 loops=10)
+    -> Index lookup on c using post_id  (cost=0.35 rows=1) (never executed)
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse plan: %s", err)
+	}
+	if root.Operation != "Nested loop inner join" || root.Cost != 4.95 || root.Time != 0.1 || len(root.Children) != 3 {
+		t.Fatalf("Incorrect root `%#v`", root)
+	}
+	filter := root.Children[0]
+	if filter.Operation != "Filter" || filter.Detail != "(p.published = 1)" || len(filter.Children) != 1 {
+		t.Fatalf("Incorrect filter `%#v`", filter)
+	}
+	if scan := filter.Children[0]; scan.Operation != "Table scan" || scan.Detail != "on p" || scan.EstimatedRows != 100 {
+		t.Fatalf("Incorrect scan `%#v`", scan)
+	}
+	lookup := root.Children[1]
+	if lookup.Detail != "on u using PRIMARY (id=p.user_id)" || lookup.ActualRows != 10 || lookup.Time < 0.039 || lookup.Time > 0.041 {
+		t.Fatalf("Loops should be multiplied in `%#v`", lookup)
+	}
+	if never := root.Children[2]; !never.HasActual || never.ActualRows != 0 {
+		t.Fatalf("Incorrect never executed node `%#v`", never)
+	}
+
+	if _, err := parseTreePlan(""); err == nil {
+		t.Fatalf("Expected an error for an empty plan")
+	}
+}
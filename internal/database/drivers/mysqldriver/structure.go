@@ -0,0 +1,105 @@
+package mysqldriver
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// Describe implements Driver.
+func (m *mysqlDriver) Describe(table database.Table) (*database.TableStructure, error) {
+	if m.config.DBName == "" {
+		return nil, errors.New("no database selected")
+	}
+	schema, name := m.config.DBName, table.(mysqlTable).name
+	structure := &database.TableStructure{}
+	if err := m.describeColumns(structure, schema, name); err != nil {
+		return nil, err
+	}
+	if err := m.describeIndexes(structure, schema, name); err != nil {
+		return nil, err
+	}
+	if err := m.describeForeignKeys(structure, schema, name); err != nil {
+		return nil, err
+	}
+	return structure, nil
+}
+
+func (m *mysqlDriver) describeColumns(structure *database.TableStructure, schema, table string) error {
+	rows, err := m.Db.Query(`
+		SELECT COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE = 'YES', COLUMN_DEFAULT, COLUMN_COMMENT
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		column := database.ColumnDefinition{}
+		var columnDefault sql.NullString
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &columnDefault, &column.Comment); err != nil {
+			return err
+		}
+		column.Default, column.HasDefault = columnDefault.String, columnDefault.Valid
+		structure.Columns = append(structure.Columns, column)
+	}
+	return rows.Err()
+}
+
+func (m *mysqlDriver) describeIndexes(structure *database.TableStructure, schema, table string) error {
+	rows, err := m.Db.Query(`
+		SELECT INDEX_NAME, NON_UNIQUE = 0, COLUMN_NAME
+		FROM information_schema.STATISTICS
+		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
+		ORDER BY INDEX_NAME = 'PRIMARY' DESC, INDEX_NAME, SEQ_IN_INDEX`, schema, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var unique bool
+		var column sql.NullString
+		if err := rows.Scan(&name, &unique, &column); err != nil {
+			return err
+		}
+		if !column.Valid {
+			// Functional key parts have no column
+			column.String = "(expression)"
+		}
+		structure.AddIndexColumn(name, unique, name == "PRIMARY", column.String)
+	}
+	return rows.Err()
+}
+
+func (m *mysqlDriver) describeForeignKeys(structure *database.TableStructure, schema, table string) error {
+	rows, err := m.Db.Query(`
+		SELECT k.CONSTRAINT_NAME, k.COLUMN_NAME, k.REFERENCED_TABLE_SCHEMA, k.REFERENCED_TABLE_NAME,
+			k.REFERENCED_COLUMN_NAME, r.UPDATE_RULE, r.DELETE_RULE
+		FROM information_schema.KEY_COLUMN_USAGE k
+		JOIN information_schema.REFERENTIAL_CONSTRAINTS r
+			ON r.CONSTRAINT_SCHEMA = k.CONSTRAINT_SCHEMA
+			AND r.CONSTRAINT_NAME = k.CONSTRAINT_NAME
+			AND r.TABLE_NAME = k.TABLE_NAME
+		WHERE k.TABLE_SCHEMA = ? AND k.TABLE_NAME = ? AND k.REFERENCED_TABLE_NAME IS NOT NULL
+		ORDER BY k.CONSTRAINT_NAME, k.ORDINAL_POSITION`, schema, table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key := database.ForeignKey{}
+		var column, referencedSchema, referencedColumn string
+		if err := rows.Scan(&key.Name, &column, &referencedSchema, &key.ReferencedTable,
+			&referencedColumn, &key.OnUpdate, &key.OnDelete); err != nil {
+			return err
+		}
+		if referencedSchema != schema {
+			key.ReferencedTable = referencedSchema + "." + key.ReferencedTable
+		}
+		structure.AddForeignKeyColumn(key, column, referencedColumn)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,66 @@
+package pgxdriver
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+var _ database.ActivityMonitor = &pgxDriver{}
+
+// Sessions implements database.ActivityMonitor.
+func (m *pgxDriver) Sessions() ([]database.Session, error) {
+	rows, err := m.Db.Query(`
+		SELECT
+			pid,
+			coalesce(usename, ''),
+			coalesce(datname, ''),
+			coalesce(state, ''),
+			extract(epoch FROM clock_timestamp() - query_start)::float8,
+			coalesce(wait_event_type || ': ' || wait_event, ''),
+			coalesce(query, '')
+		FROM pg_catalog.pg_stat_activity
+		WHERE backend_type = 'client backend' AND pid <> pg_backend_pid()
+		ORDER BY query_start NULLS LAST, pid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	sessions := []database.Session{}
+	for rows.Next() {
+		session := database.Session{}
+		var seconds sql.NullFloat64
+		err := rows.Scan(&session.ID, &session.User, &session.Database, &session.State, &seconds, &session.WaitEvent, &session.Query)
+		if err != nil {
+			return nil, err
+		}
+		session.Duration = time.Duration(seconds.Float64 * float64(time.Second))
+		session.HasDuration = seconds.Valid
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// CancelSession implements database.ActivityMonitor.
+func (m *pgxDriver) CancelSession(pid int64) error {
+	return m.signalBackend("pg_cancel_backend", pid)
+}
+
+// TerminateSession implements database.ActivityMonitor.
+func (m *pgxDriver) TerminateSession(pid int64) error {
+	return m.signalBackend("pg_terminate_backend", pid)
+}
+
+// signalBackend calls function with pid, which reports whether the backend was signalled
+func (m *pgxDriver) signalBackend(function string, pid int64) error {
+	var signalled bool
+	if err := m.Db.QueryRow(fmt.Sprintf("SELECT %s($1)", function), pid).Scan(&signalled); err != nil {
+		return err
+	}
+	if !signalled {
+		return fmt.Errorf("backend %d does not exist anymore", pid)
+	}
+	return nil
+}
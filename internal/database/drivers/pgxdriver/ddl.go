@@ -0,0 +1,220 @@
+package pgxdriver
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// DDL implements Driver.
+// Postgres has no SHOW CREATE, so the DDL is reconstructed from pg_catalog
+func (m *pgxDriver) DDL(dbTable database.Table) (string, error) {
+	table := dbTable.(pgxTable)
+	if table.kind.IsRoutine() {
+		var ddl string
+		err := m.Db.QueryRow(fmt.Sprintf("SELECT pg_get_functiondef(%d)", table.oid)).Scan(&ddl)
+		return terminate(ddl), err
+	}
+
+	var oid int64
+	var name string
+	err := m.Db.QueryRow("SELECT "+tableOid+"::oid, format('%I.%I', $1::text, $2::text)", table.schema, table.name).Scan(&oid, &name)
+	if err != nil {
+		return "", err
+	}
+	builder := &strings.Builder{}
+	switch table.kind {
+	case database.ObjectSequence:
+		err = m.sequenceDDL(builder, oid, name)
+	case database.ObjectView, database.ObjectMaterializedView:
+		err = m.viewDDL(builder, oid, name, table.kind)
+	default:
+		err = m.tableDDL(builder, oid, name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if table.kind != database.ObjectView {
+		if err := m.indexDDL(builder, oid); err != nil {
+			return "", err
+		}
+	}
+	if err := m.commentDDL(builder, oid, name, table.kind); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+func (m *pgxDriver) tableDDL(builder *strings.Builder, oid int64, name string) error {
+	lines := []string{}
+	rows, err := m.Db.Query(fmt.Sprintf(`
+		SELECT quote_ident(a.attname), format_type(a.atttypid, a.atttypmod), a.attnotnull,
+			a.attidentity::text, a.attgenerated::text, pg_get_expr(d.adbin, d.adrelid)
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = %d AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, oid))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var column, columnType, identity, generated string
+		var notNull bool
+		var expression sql.NullString
+		if err := rows.Scan(&column, &columnType, &notNull, &identity, &generated, &expression); err != nil {
+			return err
+		}
+		line := fmt.Sprintf("    %s %s", column, columnType)
+		switch {
+		case identity == "a":
+			line += " GENERATED ALWAYS AS IDENTITY"
+		case identity == "d":
+			line += " GENERATED BY DEFAULT AS IDENTITY"
+		case generated == "s":
+			line += fmt.Sprintf(" GENERATED ALWAYS AS (%s) STORED", expression.String)
+		case expression.Valid:
+			line += " DEFAULT " + expression.String
+		}
+		if notNull {
+			line += " NOT NULL"
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	constraints, err := m.Db.Query(fmt.Sprintf(`
+		SELECT quote_ident(conname), pg_get_constraintdef(oid, true)
+		FROM pg_catalog.pg_constraint
+		WHERE conrelid = %d AND contype in ('p', 'u', 'f', 'c', 'x')
+		ORDER BY contype = 'p' DESC, contype, conname`, oid))
+	if err != nil {
+		return err
+	}
+	defer constraints.Close()
+	for constraints.Next() {
+		var constraint, definition string
+		if err := constraints.Scan(&constraint, &definition); err != nil {
+			return err
+		}
+		lines = append(lines, fmt.Sprintf("    CONSTRAINT %s %s", constraint, definition))
+	}
+	if err := constraints.Err(); err != nil {
+		return err
+	}
+
+	var partitionKey sql.NullString
+	if err := m.Db.QueryRow(fmt.Sprintf("SELECT pg_get_partkeydef(%d)", oid)).Scan(&partitionKey); err != nil {
+		return err
+	}
+	fmt.Fprintf(builder, "CREATE TABLE %s (\n%s\n)", name, strings.Join(lines, ",\n"))
+	if partitionKey.Valid {
+		builder.WriteString(" PARTITION BY " + partitionKey.String)
+	}
+	builder.WriteString(";\n")
+	return nil
+}
+
+func (m *pgxDriver) viewDDL(builder *strings.Builder, oid int64, name string, kind database.ObjectKind) error {
+	var definition string
+	if err := m.Db.QueryRow(fmt.Sprintf("SELECT pg_get_viewdef(%d, true)", oid)).Scan(&definition); err != nil {
+		return err
+	}
+	fmt.Fprintf(builder, "CREATE %s %s AS\n%s\n", strings.ToUpper(kind.String()), name, terminate(definition))
+	return nil
+}
+
+func (m *pgxDriver) sequenceDDL(builder *strings.Builder, oid int64, name string) error {
+	var sequenceType string
+	var start, increment, minimum, maximum, cache int64
+	var cycle bool
+	err := m.Db.QueryRow(fmt.Sprintf(`
+		SELECT format_type(seqtypid, NULL), seqstart, seqincrement, seqmin, seqmax, seqcache, seqcycle
+		FROM pg_catalog.pg_sequence
+		WHERE seqrelid = %d`, oid)).Scan(&sequenceType, &start, &increment, &minimum, &maximum, &cache, &cycle)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(builder, "CREATE SEQUENCE %s\n    AS %s\n    START WITH %d\n    INCREMENT BY %d\n    MINVALUE %d\n    MAXVALUE %d\n    CACHE %d",
+		name, sequenceType, start, increment, minimum, maximum, cache)
+	if cycle {
+		builder.WriteString("\n    CYCLE")
+	}
+	builder.WriteString(";\n")
+	return nil
+}
+
+// indexDDL adds the indexes that are not created by a constraint
+func (m *pgxDriver) indexDDL(builder *strings.Builder, oid int64) error {
+	rows, err := m.Db.Query(fmt.Sprintf(`
+		SELECT pg_get_indexdef(i.indexrelid)
+		FROM pg_catalog.pg_index i
+		WHERE i.indrelid = %d AND NOT EXISTS (
+			SELECT FROM pg_catalog.pg_constraint c WHERE c.conindid = i.indexrelid AND c.conrelid = i.indrelid
+		)
+		ORDER BY i.indexrelid::regclass::text`, oid))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	first := true
+	for rows.Next() {
+		var index string
+		if err := rows.Scan(&index); err != nil {
+			return err
+		}
+		if first {
+			builder.WriteByte('\n')
+			first = false
+		}
+		builder.WriteString(terminate(index) + "\n")
+	}
+	return rows.Err()
+}
+
+func (m *pgxDriver) commentDDL(builder *strings.Builder, oid int64, name string, kind database.ObjectKind) error {
+	rows, err := m.Db.Query(fmt.Sprintf(`
+		SELECT coalesce(quote_ident(a.attname), ''), d.description
+		FROM pg_catalog.pg_description d
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = d.objoid AND a.attnum = d.objsubid AND d.objsubid > 0
+		WHERE d.objoid = %d AND d.classoid = 'pg_catalog.pg_class'::regclass
+		ORDER BY d.objsubid`, oid))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	first := true
+	for rows.Next() {
+		var column, comment string
+		if err := rows.Scan(&column, &comment); err != nil {
+			return err
+		}
+		if first {
+			builder.WriteByte('\n')
+			first = false
+		}
+		if column == "" {
+			fmt.Fprintf(builder, "COMMENT ON %s %s IS %s;\n", strings.ToUpper(kind.String()), name, quoteLiteral(comment))
+		} else {
+			fmt.Fprintf(builder, "COMMENT ON COLUMN %s.%s IS %s;\n", name, column, quoteLiteral(comment))
+		}
+	}
+	return rows.Err()
+}
+
+// terminate trims statement and makes sure it ends with a semicolon
+func terminate(statement string) string {
+	statement = strings.TrimSpace(statement)
+	if !strings.HasSuffix(statement, ";") {
+		statement += ";"
+	}
+	return statement
+}
+
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -1,18 +1,42 @@
 package pgxdriver
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Kavantix/lazysql/internal/credentials"
 	"github.com/Kavantix/lazysql/internal/database"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/stdlib"
 )
 
 var _ database.Driver = &pgxDriver{}
 var _ database.Table = pgxTable{}
 
+// Name is the type of postgres hosts in the config, it is the default type of new hosts
+const Name = "postgresql"
+
+const defaultPort = 5432
+
+func init() {
+	database.Register(database.DriverInfo{
+		Name:        Name,
+		Aliases:     []string{"postgres"},
+		DefaultPort: defaultPort,
+		Fields:      []database.Field{database.FieldHost, database.FieldPort, database.FieldUser, database.FieldPassword},
+		Credentials: credentials.Postgres,
+		New:         NewPgxDriver,
+	})
+}
+
 type pgxDriver struct {
 	database.BaseDriver
 	config pgx.ConnConfig
@@ -22,6 +46,11 @@ type pgxTable struct {
 	schema        string
 	name          string
 	longestSchema string
+	kind          database.ObjectKind
+	// oid identifies functions and procedures, which can be overloaded
+	oid uint32
+	// arguments are the identity arguments of a function or procedure
+	arguments string
 }
 
 // EqualsTable implements database.Table.
@@ -40,12 +69,28 @@ func (t pgxTable) DisplayString() string {
 		builder.WriteString(" | ")
 	}
 	builder.WriteString(t.name)
+	if t.kind.IsRoutine() {
+		builder.WriteString("(" + t.arguments + ")")
+	}
 	return builder.String()
 }
 
+// Kind implements database.Table.
+func (t pgxTable) Kind() database.ObjectKind {
+	return t.kind
+}
+
+// Dialect implements Driver.
+func (m *pgxDriver) Dialect() database.Dialect {
+	return database.DialectPostgres
+}
+
 // QueryForTable implements Driver.
-func (m *pgxDriver) QueryForTable(dbTable database.Table, limit int) database.Query {
+func (m *pgxDriver) QueryForTable(dbTable database.Table) database.Query {
 	table := dbTable.(pgxTable)
+	if table.kind.IsRoutine() {
+		return database.Query(fmt.Sprintf("SELECT pg_get_functiondef(%d)", table.oid))
+	}
 	prefix := strings.Builder{}
 	if table.schema != "public" {
 		prefix.WriteByte('"')
@@ -53,31 +98,163 @@ func (m *pgxDriver) QueryForTable(dbTable database.Table, limit int) database.Qu
 		prefix.WriteByte('"')
 		prefix.WriteByte('.')
 	}
-	return database.Query(fmt.Sprintf("SELECT *\nFROM %s\"%s\"\nLIMIT %d", prefix.String(), table.name, limit))
+	return database.Query(fmt.Sprintf("SELECT *\nFROM %s\"%s\"", prefix.String(), table.name))
+}
+
+// connectionURL builds the url pgx reads the settings of dsn from,
+// the user and password are escaped since they can contain characters like @ and /
+func connectionURL(dsn database.Dsn) string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(dsn.User, dsn.Password),
+		Host:   net.JoinHostPort(dsn.Host, strconv.Itoa(int(dsn.Port))),
+	}
+	if dsn.TLS.Mode != database.TLSDefault {
+		u.RawQuery = url.Values{"sslmode": {string(dsn.TLS.Mode)}}.Encode()
+	}
+	return u.String()
 }
 
 func NewPgxDriver(dsn database.Dsn) (database.Driver, error) {
 	if dsn.Port == 0 {
-		dsn.Port = 5432
+		dsn.Port = defaultPort
 	}
-	url := fmt.Sprintf("postgres://%s:%s@%s:%d",
-		dsn.User, dsn.Password, dsn.Host, dsn.Port)
-	config, err := pgx.ParseConfig(url)
+	config, err := pgx.ParseConfig(connectionURL(dsn))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
-	// config.TLSConfig = nil
+	if err := applyTLS(&config.Config, dsn); err != nil {
+		return nil, err
+	}
+	if dsn.Dialer != nil {
+		config.DialFunc = dsn.Dialer.DialContext
+		// The host is resolved by the other end of the tunnel
+		config.LookupFunc = func(ctx context.Context, host string) ([]string, error) {
+			return []string{host}, nil
+		}
+	}
 
 	driver := &pgxDriver{
-		config: *config,
 		BaseDriver: database.BaseDriver{
-			Db: stdlib.OpenDB(*config),
+			ColumnKind:     columnKind,
+			ExecConn:       execConn,
+			Dialer:         dsn.Dialer,
+			QueryTimeout:   dsn.QueryTimeout,
+			ServerTimeout:  serverTimeout,
+			IsTimeout:      isTimeout,
+			ConnectionID:   connectionID,
+			CancelOnServer: cancelOnServer,
 		},
 	}
+	config.OnNotice = func(_ *pgconn.PgConn, notice *pgconn.Notice) {
+		driver.AddMessage(noticeMessage(notice))
+	}
+	driver.config = *config
+	driver.Db = stdlib.OpenDB(*config)
 
 	return driver, nil
 }
 
+// noticeMessage converts a notice like the output of RAISE NOTICE to a message
+func noticeMessage(notice *pgconn.Notice) database.Message {
+	text := notice.Message
+	if notice.Detail != "" {
+		text += "\n" + notice.Detail
+	}
+	if notice.Hint != "" {
+		text += "\nHint: " + notice.Hint
+	}
+	return database.Message{
+		Severity: notice.Severity,
+		Code:     notice.Code,
+		Text:     text,
+	}
+}
+
+// applyTLS replaces the TLS configs pgx derived from the sslmode with the one of dsn,
+// the plain fallback of the prefer mode is kept
+func applyTLS(config *pgconn.Config, dsn database.Dsn) error {
+	if dsn.TLS.Mode == database.TLSDisable {
+		return nil
+	}
+	tlsConfig, err := dsn.TLS.Config(dsn.Host)
+	if err != nil {
+		return err
+	}
+	if config.TLSConfig != nil {
+		config.TLSConfig = tlsConfig
+	}
+	for _, fallback := range config.Fallbacks {
+		if fallback.TLSConfig != nil {
+			fallback.TLSConfig = tlsConfig
+		}
+	}
+	return nil
+}
+
+// execConn executes the query using pgx directly to be able to report the command tag
+func execConn(ctx context.Context, conn *sql.Conn, query database.Query, args []any) (*database.ExecResult, error) {
+	var result *database.ExecResult
+	err := conn.Raw(func(driverConn any) error {
+		tag, err := driverConn.(*stdlib.Conn).Conn().Exec(ctx, string(query), args...)
+		if err != nil {
+			return err
+		}
+		result = &database.ExecResult{
+			RowsAffected: tag.RowsAffected(),
+			CommandTag:   tag.String(),
+		}
+		return nil
+	})
+	return result, err
+}
+
+// serverTimeout sets the statement_timeout of the session of conn
+func serverTimeout(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+	_, err := conn.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", timeout.Milliseconds()))
+	return err
+}
+
+// isTimeout reports whether err is a statement cancelled because of statement_timeout,
+// the same code is used when a statement is cancelled on request
+func isTimeout(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "57014" && strings.Contains(pgErr.Message, "statement timeout")
+}
+
+// connectionID returns the process id of the backend of conn
+func connectionID(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var pid uint32
+	err := conn.Raw(func(driverConn any) error {
+		pid = driverConn.(*stdlib.Conn).Conn().PgConn().PID()
+		return nil
+	})
+	return int64(pid), err
+}
+
+// cancelOnServer cancels the statement of the backend with pid,
+// pg_cancel_backend reports whether the backend was signalled
+func cancelOnServer(ctx context.Context, db *sql.DB, pid int64) (bool, error) {
+	var cancelled bool
+	err := db.QueryRowContext(ctx, "SELECT pg_cancel_backend($1)", pid).Scan(&cancelled)
+	return cancelled, err
+}
+
+func columnKind(databaseType string) database.ColumnKind {
+	switch databaseType {
+	case "INT2", "INT4", "INT8", "FLOAT4", "FLOAT8", "NUMERIC", "OID", "MONEY":
+		return database.KindNumber
+	case "BOOL":
+		return database.KindBool
+	case "DATE", "TIME", "TIMETZ", "TIMESTAMP", "TIMESTAMPTZ", "INTERVAL":
+		return database.KindTime
+	case "BYTEA":
+		return database.KindBinary
+	default:
+		return database.KindText
+	}
+}
+
 func (m *pgxDriver) Databases() ([]database.Database, error) {
 	databases := []database.Database{}
 	rows, err := m.Db.Query("select datname from pg_catalog.pg_database where not datistemplate order by datname")
@@ -101,6 +278,9 @@ func (m *pgxDriver) SelectDatabase(db database.Database) error {
 	if m.config.Database == string(db) {
 		return nil
 	}
+	if open, _ := m.Transaction(); open {
+		return errors.New("cannot change database while a transaction is open")
+	}
 	if m.Db != nil {
 		go m.Db.Close()
 		m.config.Database = string(db)
@@ -115,27 +295,46 @@ func (m *pgxDriver) Tables() ([]database.Table, error) {
 	}
 	var result []database.Table
 	tables := []pgxTable{}
-	whereClause := "where schemaname not in ('pg_catalog', 'information_schema')"
+	whereClause := "and n.nspname not in ('pg_catalog', 'information_schema') and n.nspname not like 'pg_toast%'"
 	if m.config.Database == "postgres" {
 		whereClause = ""
 	}
-	rows, err := m.Db.Query(fmt.Sprintf("SELECT schemaname, tablename FROM pg_catalog.pg_tables %s order by schemaname = 'public' desc, schemaname, tablename", whereClause))
+	rows, err := m.Db.Query(fmt.Sprintf(`
+		SELECT schema, name, kind, oid, arguments FROM (
+			SELECT n.nspname AS schema, c.relname AS name,
+				-- Partitioned tables are listed as tables, 'p' is used for procedures
+				CASE c.relkind WHEN 'p' THEN 'r' ELSE c.relkind::text END AS kind,
+				0::oid AS oid, '' AS arguments
+			FROM pg_catalog.pg_class c
+			JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind in ('r', 'p', 'v', 'm', 'S') %[1]s
+			UNION ALL
+			SELECT n.nspname, p.proname, p.prokind::text, p.oid, pg_get_function_identity_arguments(p.oid)
+			FROM pg_catalog.pg_proc p
+			JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace
+			WHERE p.prokind in ('f', 'p') %[1]s
+		) objects
+		order by schema = 'public' desc, schema, name`, whereClause))
 	if err != nil {
 		return result, err
 	}
-	index := 0
+	defer rows.Close()
 	longestSchema := ""
 	for rows.Next() {
 		table := pgxTable{}
-		err := rows.Scan(&table.schema, &table.name)
+		var kind string
+		err := rows.Scan(&table.schema, &table.name, &kind, &table.oid, &table.arguments)
 		if err != nil {
 			return result, err
 		}
+		table.kind = objectKind(kind)
 		if len(table.schema) > len(longestSchema) {
 			longestSchema = table.schema
 		}
 		tables = append(tables, table)
-		index += 1
+	}
+	if err := rows.Err(); err != nil {
+		return result, err
 	}
 	result = make([]database.Table, len(tables))
 	for i, table := range tables {
@@ -144,3 +343,21 @@ func (m *pgxDriver) Tables() ([]database.Table, error) {
 	}
 	return result, nil
 }
+
+// objectKind converts a relkind of pg_class or a prokind of pg_proc to an ObjectKind
+func objectKind(kind string) database.ObjectKind {
+	switch kind {
+	case "v":
+		return database.ObjectView
+	case "m":
+		return database.ObjectMaterializedView
+	case "S":
+		return database.ObjectSequence
+	case "f":
+		return database.ObjectFunction
+	case "p":
+		return database.ObjectProcedure
+	default:
+		return database.ObjectTable
+	}
+}
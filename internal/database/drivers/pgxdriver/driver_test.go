@@ -0,0 +1,73 @@
+package pgxdriver
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestApplyTLS(t *testing.T) {
+	config, err := pgx.ParseConfig("postgres://user@db.internal:5432?sslmode=verify-full")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	dsn := database.Dsn{Host: "db.internal", TLS: database.TLS{Mode: database.TLSVerifyFull, ServerName: "db.example.com"}}
+	if err := applyTLS(&config.Config, dsn); err != nil {
+		t.Fatalf("Failed to apply TLS: %s", err)
+	}
+	if config.TLSConfig == nil || config.TLSConfig.ServerName != "db.example.com" || config.TLSConfig.InsecureSkipVerify {
+		t.Fatalf("Incorrect TLS config `%#v`", config.TLSConfig)
+	}
+
+	config, err = pgx.ParseConfig("postgres://user@db.internal:5432?sslmode=prefer")
+	if err != nil {
+		t.Fatalf("Failed to parse config: %s", err)
+	}
+	if err := applyTLS(&config.Config, database.Dsn{Host: "db.internal"}); err != nil {
+		t.Fatalf("Failed to apply TLS: %s", err)
+	}
+	if config.TLSConfig == nil || len(config.Fallbacks) != 1 || config.Fallbacks[0].TLSConfig != nil {
+		t.Fatalf("Prefer should keep the plain fallback `%#v`", config.Fallbacks)
+	}
+}
+
+func TestConnectionURL(t *testing.T) {
+	tests := []database.Dsn{
+		{Host: "db.internal", Port: 5432, User: "admin", Password: "p@ss/w?rd#%"},
+		{Host: "db.internal", Port: 6543, User: "ad:m@in", Password: "a:b c%20d"},
+		{Host: "::1", Port: 5432, User: "admin", Password: "secret", TLS: database.TLS{Mode: database.TLSVerifyFull}},
+	}
+	for _, dsn := range tests {
+		config, err := pgx.ParseConfig(connectionURL(dsn))
+		if err != nil {
+			t.Fatalf("Failed to parse config of %#v: %s", dsn, err)
+		}
+		if config.Host != dsn.Host || config.Port != dsn.Port || config.User != dsn.User || config.Password != dsn.Password {
+			t.Fatalf("Incorrect config for %#v: %s:%d %s %s", dsn, config.Host, config.Port, config.User, config.Password)
+		}
+	}
+}
+
+func TestIsTimeout(t *testing.T) {
+	if !isTimeout(fmt.Errorf("query failed: %w", &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"})) {
+		t.Fatalf("statement_timeout should be a timeout")
+	}
+	if isTimeout(&pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"}) {
+		t.Fatalf("A cancelled statement is not a timeout")
+	}
+}
+
+func TestNoticeMessage(t *testing.T) {
+	message := noticeMessage(&pgconn.Notice{
+		Severity: "NOTICE",
+		Code:     "00000",
+		Message:  "relation \"users\" already exists, skipping",
+		Hint:     "use another name",
+	})
+	if message.String() != "[NOTICE 00000]: relation \"users\" already exists, skipping\nHint: use another name" {
+		t.Fatalf("Incorrect message: %s", message)
+	}
+}
@@ -0,0 +1,105 @@
+package pgxdriver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// Explain implements Driver.
+func (m *pgxDriver) Explain(query database.Query, analyze bool) (*database.Plan, error) {
+	options := "FORMAT JSON"
+	if analyze {
+		options = "ANALYZE, FORMAT JSON"
+	}
+	results, err := m.Query(database.Query(fmt.Sprintf("EXPLAIN (%s) %s", options, query)))
+	if err != nil {
+		return nil, err
+	}
+	value, err := database.FirstValue(results)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parsePlan([]byte(value))
+	if err != nil {
+		return nil, err
+	}
+	return &database.Plan{Root: root, Analyzed: analyze}, nil
+}
+
+type planNode struct {
+	NodeType        string     `json:"Node Type"`
+	Strategy        string     `json:"Strategy"`
+	JoinType        string     `json:"Join Type"`
+	RelationName    string     `json:"Relation Name"`
+	Alias           string     `json:"Alias"`
+	IndexName       string     `json:"Index Name"`
+	CteName         string     `json:"CTE Name"`
+	FunctionName    string     `json:"Function Name"`
+	TotalCost       float64    `json:"Total Cost"`
+	PlanRows        float64    `json:"Plan Rows"`
+	ActualRows      *float64   `json:"Actual Rows"`
+	ActualTotalTime float64    `json:"Actual Total Time"`
+	ActualLoops     float64    `json:"Actual Loops"`
+	Plans           []planNode `json:"Plans"`
+}
+
+// parsePlan parses the output of EXPLAIN (FORMAT JSON)
+func parsePlan(data []byte) (*database.PlanNode, error) {
+	explained := []struct {
+		Plan planNode `json:"Plan"`
+	}{}
+	if err := json.Unmarshal(data, &explained); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(explained) == 0 {
+		return nil, errors.New("failed to parse plan: no plan returned")
+	}
+	return explained[0].Plan.toPlanNode(), nil
+}
+
+func (n planNode) toPlanNode() *database.PlanNode {
+	details := []string{}
+	if n.Strategy != "" && n.Strategy != "Plain" {
+		details = append(details, n.Strategy)
+	}
+	if n.JoinType != "" {
+		details = append(details, n.JoinType+" join")
+	}
+	if n.RelationName != "" {
+		relation := "on " + n.RelationName
+		if n.Alias != "" && n.Alias != n.RelationName {
+			relation += " " + n.Alias
+		}
+		details = append(details, relation)
+	}
+	if n.IndexName != "" {
+		details = append(details, "using "+n.IndexName)
+	}
+	if n.CteName != "" {
+		details = append(details, "CTE "+n.CteName)
+	}
+	if n.FunctionName != "" {
+		details = append(details, "function "+n.FunctionName)
+	}
+	node := &database.PlanNode{
+		Operation:     n.NodeType,
+		Detail:        strings.Join(details, ", "),
+		EstimatedRows: n.PlanRows,
+		Cost:          n.TotalCost,
+		HasEstimate:   true,
+	}
+	if n.ActualRows != nil {
+		// Actual values are averages per loop
+		node.HasActual = true
+		node.ActualRows = *n.ActualRows * n.ActualLoops
+		node.Time = n.ActualTotalTime * n.ActualLoops
+	}
+	for _, child := range n.Plans {
+		node.Children = append(node.Children, child.toPlanNode())
+	}
+	return node
+}
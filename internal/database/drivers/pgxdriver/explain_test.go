@@ -0,0 +1,43 @@
+package pgxdriver
+
+import (
+	"testing"
+)
+
+func TestParsePlan(t *testing.T) {
+	root, err := parsePlan([]byte(`[{
+		"Plan": {
+			"Node Type": "Hash Join", "Join Type": "Inner", "Total Cost": 40.5, "Plan Rows": 100,
+			"Actual Rows": 90, "Actual Total Time": 2.5, "Actual Loops": 1,
+			"Plans": [
+				{"Node Type": "Seq Scan", "Relation Name": "posts", "Alias": "p", "Total Cost": 30, "Plan Rows": 1000,
+					"Actual Rows": 900, "Actual Total Time": 1.5, "Actual Loops": 1},
+				{"Node Type": "Index Scan", "Relation Name": "users", "Alias": "users", "Index Name": "users_pkey",
+					"Total Cost": 8.25, "Plan Rows": 1, "Actual Rows": 1, "Actual Total Time": 0.1, "Actual Loops": 3}
+			]
+		},
+		"Planning Time": 0.1,
+		"Execution Time": 2.6
+	}]`))
+	if err != nil {
+		t.Fatalf("Failed to parse plan: %s", err)
+	}
+	if root.Operation != "Hash Join" || root.Detail != "Inner join" || len(root.Children) != 2 {
+		t.Fatalf("Incorrect root `%#v`", root)
+	}
+	scan := root.Children[0]
+	if scan.Detail != "on posts p" || scan.EstimatedRows != 1000 || !scan.HasActual || scan.ActualRows != 900 {
+		t.Fatalf("Incorrect scan `%#v`", scan)
+	}
+	index := root.Children[1]
+	if index.Detail != "on users, using users_pkey" || index.ActualRows != 3 || index.Time < 0.29 || index.Time > 0.31 {
+		t.Fatalf("Loops should be multiplied in `%#v`", index)
+	}
+	if cost := root.ExclusiveCost(false); cost < 2.24 || cost > 2.26 {
+		t.Fatalf("Incorrect exclusive cost %f", cost)
+	}
+
+	if _, err := parsePlan([]byte(`{}`)); err == nil {
+		t.Fatalf("Expected an error for invalid plans")
+	}
+}
@@ -0,0 +1,174 @@
+package pgxdriver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var _ database.Listener = &pgxDriver{}
+
+// connectTimeout limits how long opening the connection of a subscription can take
+const connectTimeout = 10 * time.Second
+
+var errSubscriptionClosed = errors.New("the subscription is closed")
+
+// Subscribe implements database.Listener.
+func (m *pgxDriver) Subscribe(onNotification func(database.Notification), onError func(error)) (database.Subscription, error) {
+	config := m.config.Copy()
+	// Notices of the subscription are not part of the running query
+	config.OnNotice = nil
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	conn, err := pgx.ConnectConfig(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	s := &subscription{
+		conn:     conn,
+		requests: make(chan listenRequest),
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+	go s.run(ctx, onNotification, onError)
+	return s, nil
+}
+
+// Notify implements database.Listener.
+func (m *pgxDriver) Notify(channel, payload string) error {
+	_, err := m.Db.Exec("SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
+
+// subscription owns a connection that waits for notifications,
+// waiting is interrupted to execute LISTEN and UNLISTEN since the connection can do one thing at a time
+type subscription struct {
+	conn     *pgx.Conn
+	requests chan listenRequest
+	cancel   context.CancelFunc
+	// done is closed once run stopped using the connection
+	done chan struct{}
+
+	mutex    sync.Mutex
+	channels []string
+}
+
+// listenRequest is a statement for run to execute on the connection
+type listenRequest struct {
+	statement string
+	result    chan error
+}
+
+type waitResult struct {
+	notification *pgconn.Notification
+	err          error
+}
+
+func (s *subscription) run(ctx context.Context, onNotification func(database.Notification), onError func(error)) {
+	defer close(s.done)
+	deliver := func(notification *pgconn.Notification) {
+		if notification != nil {
+			onNotification(database.Notification{
+				At:      time.Now(),
+				Channel: notification.Channel,
+				Payload: notification.Payload,
+				PID:     notification.PID,
+			})
+		}
+	}
+	for {
+		waitContext, stopWaiting := context.WithCancel(ctx)
+		received := make(chan waitResult, 1)
+		go func() {
+			notification, err := s.conn.WaitForNotification(waitContext)
+			received <- waitResult{notification, err}
+		}()
+
+		select {
+		case request := <-s.requests:
+			stopWaiting()
+			result := <-received
+			deliver(result.notification)
+			if s.conn.IsClosed() {
+				request.result <- result.err
+				onError(result.err)
+				return
+			}
+			_, err := s.conn.Exec(ctx, request.statement)
+			request.result <- err
+		case result := <-received:
+			stopWaiting()
+			if result.err != nil {
+				if ctx.Err() == nil {
+					onError(result.err)
+				}
+				return
+			}
+			deliver(result.notification)
+		}
+	}
+}
+
+// exec has run execute statement in between waiting for notifications
+func (s *subscription) exec(statement string) error {
+	request := listenRequest{
+		statement: statement,
+		result:    make(chan error, 1),
+	}
+	select {
+	case s.requests <- request:
+		return <-request.result
+	case <-s.done:
+		return errSubscriptionClosed
+	}
+}
+
+// Listen implements database.Subscription.
+func (s *subscription) Listen(channel string) error {
+	if err := s.exec("LISTEN " + pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !slices.Contains(s.channels, channel) {
+		s.channels = append(s.channels, channel)
+	}
+	return nil
+}
+
+// Unlisten implements database.Subscription.
+func (s *subscription) Unlisten(channel string) error {
+	if err := s.exec("UNLISTEN " + pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.channels = slices.DeleteFunc(s.channels, func(listened string) bool {
+		return listened == channel
+	})
+	return nil
+}
+
+// Channels implements database.Subscription.
+func (s *subscription) Channels() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.channels)
+}
+
+// Close implements database.Subscription.
+func (s *subscription) Close() error {
+	s.cancel()
+	<-s.done
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	return s.conn.Close(ctx)
+}
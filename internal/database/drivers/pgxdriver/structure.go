@@ -0,0 +1,120 @@
+package pgxdriver
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// tableOid resolves the table from the schema and name given as $1 and $2
+const tableOid = "format('%I.%I', $1::text, $2::text)::regclass"
+
+// Describe implements Driver.
+func (m *pgxDriver) Describe(dbTable database.Table) (*database.TableStructure, error) {
+	if m.config.Database == "" {
+		return nil, errors.New("no database selected")
+	}
+	table := dbTable.(pgxTable)
+	structure := &database.TableStructure{}
+	if err := m.describeColumns(structure, table); err != nil {
+		return nil, err
+	}
+	if err := m.describeIndexes(structure, table); err != nil {
+		return nil, err
+	}
+	if err := m.describeForeignKeys(structure, table); err != nil {
+		return nil, err
+	}
+	return structure, nil
+}
+
+func (m *pgxDriver) describeColumns(structure *database.TableStructure, table pgxTable) error {
+	rows, err := m.Db.Query(`
+		SELECT a.attname, format_type(a.atttypid, a.atttypmod), NOT a.attnotnull,
+			pg_get_expr(d.adbin, d.adrelid), coalesce(col_description(a.attrelid, a.attnum), '')
+		FROM pg_catalog.pg_attribute a
+		LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE a.attrelid = `+tableOid+` AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, table.schema, table.name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		column := database.ColumnDefinition{}
+		var columnDefault sql.NullString
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &columnDefault, &column.Comment); err != nil {
+			return err
+		}
+		column.Default, column.HasDefault = columnDefault.String, columnDefault.Valid
+		structure.Columns = append(structure.Columns, column)
+	}
+	return rows.Err()
+}
+
+func (m *pgxDriver) describeIndexes(structure *database.TableStructure, table pgxTable) error {
+	rows, err := m.Db.Query(`
+		SELECT i.relname, ix.indisunique, ix.indisprimary, pg_get_indexdef(ix.indexrelid, k.n, true)
+		FROM pg_catalog.pg_index ix
+		JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+		CROSS JOIN LATERAL generate_series(1, ix.indnatts) AS k(n)
+		WHERE ix.indrelid = `+tableOid+`
+		ORDER BY ix.indisprimary DESC, i.relname, k.n`, table.schema, table.name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		if err := rows.Scan(&name, &unique, &primary, &column); err != nil {
+			return err
+		}
+		structure.AddIndexColumn(name, unique, primary, column)
+	}
+	return rows.Err()
+}
+
+func (m *pgxDriver) describeForeignKeys(structure *database.TableStructure, table pgxTable) error {
+	rows, err := m.Db.Query(`
+		SELECT c.conname, a.attname, c.confrelid::regclass::text, fa.attname,
+			c.confupdtype::text, c.confdeltype::text
+		FROM pg_catalog.pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, fattnum, n)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		JOIN pg_catalog.pg_attribute fa ON fa.attrelid = c.confrelid AND fa.attnum = k.fattnum
+		WHERE c.conrelid = `+tableOid+` AND c.contype = 'f'
+		ORDER BY c.conname, k.n`, table.schema, table.name)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		key := database.ForeignKey{}
+		var column, referencedColumn, onUpdate, onDelete string
+		if err := rows.Scan(&key.Name, &column, &key.ReferencedTable, &referencedColumn, &onUpdate, &onDelete); err != nil {
+			return err
+		}
+		key.OnUpdate = referentialAction(onUpdate)
+		key.OnDelete = referentialAction(onDelete)
+		structure.AddForeignKeyColumn(key, column, referencedColumn)
+	}
+	return rows.Err()
+}
+
+// referentialAction converts the action codes used in pg_constraint to their sql
+func referentialAction(code string) string {
+	switch code {
+	case "r":
+		return "RESTRICT"
+	case "c":
+		return "CASCADE"
+	case "n":
+		return "SET NULL"
+	case "d":
+		return "SET DEFAULT"
+	default:
+		return "NO ACTION"
+	}
+}
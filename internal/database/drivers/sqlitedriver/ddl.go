@@ -0,0 +1,38 @@
+package sqlitedriver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// DDL implements Driver.
+// The DDL of a table includes its indexes and triggers
+func (m *sqliteDriver) DDL(dbTable database.Table) (string, error) {
+	table := dbTable.(sqliteTable)
+	rows, err := m.Db.Query(fmt.Sprintf(`
+		SELECT sql FROM %s.sqlite_master
+		WHERE tbl_name = ? AND sql IS NOT NULL
+		ORDER BY type IN ('table', 'view') DESC, type, name`, quoteIdentifier(table.schema)), table.name)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	statements := []string{}
+	for rows.Next() {
+		var statement string
+		if err := rows.Scan(&statement); err != nil {
+			return "", err
+		}
+		statements = append(statements, statement+";")
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(statements) == 0 {
+		return "", errors.New("no DDL found")
+	}
+	return strings.Join(statements, "\n\n") + "\n", nil
+}
@@ -0,0 +1,172 @@
+package sqlitedriver
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+	_ "modernc.org/sqlite"
+)
+
+var _ database.Driver = &sqliteDriver{}
+
+func init() {
+	database.Register(database.DriverInfo{
+		Name:     "sqlite",
+		Aliases:  []string{"sqlite3"},
+		Fields:   []database.Field{database.FieldFile},
+		Required: []database.Field{database.FieldFile},
+		New:      NewSqliteDriver,
+	})
+}
+
+var _ database.Table = sqliteTable{}
+
+type sqliteDriver struct {
+	database.BaseDriver
+	schema string
+}
+
+type sqliteTable struct {
+	schema string
+	name   string
+	kind   database.ObjectKind
+}
+
+// EqualsTable implements database.Table.
+func (t sqliteTable) EqualsTable(other database.Table) bool {
+	otherTable, ok := other.(sqliteTable)
+	return ok && t == otherTable
+}
+
+func (t sqliteTable) DisplayString() string {
+	return t.name
+}
+
+// Kind implements database.Table.
+func (t sqliteTable) Kind() database.ObjectKind {
+	return t.kind
+}
+
+// Dialect implements Driver.
+func (m *sqliteDriver) Dialect() database.Dialect {
+	return database.DialectSqlite
+}
+
+// QueryForTable implements Driver.
+func (m *sqliteDriver) QueryForTable(dbTable database.Table) database.Query {
+	table := dbTable.(sqliteTable)
+	prefix := ""
+	if table.schema != "main" {
+		prefix = quoteIdentifier(table.schema) + "."
+	}
+	return database.Query(fmt.Sprintf("SELECT *\nFROM %s%s", prefix, quoteIdentifier(table.name)))
+}
+
+func NewSqliteDriver(dsn database.Dsn) (database.Driver, error) {
+	if dsn.File == "" {
+		return nil, errors.New("no database file given")
+	}
+	// Opening a file that does not exist would silently create an empty database
+	if _, err := os.Stat(dsn.File); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", dsn.File)
+	if err != nil {
+		return nil, err
+	}
+
+	driver := &sqliteDriver{
+		BaseDriver: database.BaseDriver{
+			Db:           db,
+			ColumnKind:   columnKind,
+			QueryTimeout: dsn.QueryTimeout,
+		},
+	}
+
+	return driver, nil
+}
+
+// columnKind determines the kind of a column from its declared type
+// using the same rules sqlite uses to determine type affinity
+func columnKind(databaseType string) database.ColumnKind {
+	switch {
+	case strings.Contains(databaseType, "INT"),
+		strings.Contains(databaseType, "REAL"),
+		strings.Contains(databaseType, "FLOA"),
+		strings.Contains(databaseType, "DOUB"),
+		strings.Contains(databaseType, "NUMERIC"),
+		strings.Contains(databaseType, "DECIMAL"):
+		return database.KindNumber
+	case strings.Contains(databaseType, "BOOL"):
+		return database.KindBool
+	case strings.Contains(databaseType, "DATE"),
+		strings.Contains(databaseType, "TIME"):
+		return database.KindTime
+	case strings.Contains(databaseType, "BLOB"):
+		return database.KindBinary
+	default:
+		return database.KindText
+	}
+}
+
+// Databases returns the main database and all attached databases
+func (m *sqliteDriver) Databases() ([]database.Database, error) {
+	databases := []database.Database{}
+	rows, err := m.Db.Query("SELECT name FROM pragma_database_list ORDER BY seq")
+	if err != nil {
+		return databases, err
+	}
+	defer rows.Close()
+	index := 0
+	for rows.Next() {
+		databases = append(databases, "")
+		err := rows.Scan(&databases[index])
+		if err != nil {
+			return databases, err
+		}
+		index += 1
+	}
+	return databases, rows.Err()
+}
+
+// SelectDatabase selects which of the attached databases is used for listing tables
+func (m *sqliteDriver) SelectDatabase(db database.Database) error {
+	m.schema = string(db)
+	return nil
+}
+
+func (m *sqliteDriver) Tables() ([]database.Table, error) {
+	if m.schema == "" {
+		return nil, errors.New("no database selected")
+	}
+	tables := []database.Table{}
+	rows, err := m.Db.Query(fmt.Sprintf(
+		"SELECT name, type = 'view' FROM %s.sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%%' ORDER BY name",
+		quoteIdentifier(m.schema),
+	))
+	if err != nil {
+		return tables, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		table := sqliteTable{schema: m.schema}
+		var isView bool
+		err := rows.Scan(&table.name, &isView)
+		if err != nil {
+			return tables, err
+		}
+		if isView {
+			table.kind = database.ObjectView
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+func quoteIdentifier(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
@@ -0,0 +1,685 @@
+package sqlitedriver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+func createTestDatabase(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %s", err)
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, avatar BLOB);
+		INSERT INTO users (name, avatar) VALUES ('alice', x'cafe'), ('bob', NULL);
+		CREATE VIEW user_names AS SELECT name FROM users;
+	`)
+	if err != nil {
+		t.Fatalf("Failed to fill database: %s", err)
+	}
+	return path
+}
+
+func TestMissingFile(t *testing.T) {
+	_, err := NewSqliteDriver(database.Dsn{File: filepath.Join(t.TempDir(), "missing.db")})
+	if err == nil {
+		t.Fatalf("Expected an error when opening a file that does not exist")
+	}
+}
+
+func TestTablesAndQuery(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	databases, err := driver.Databases()
+	if err != nil {
+		t.Fatalf("Failed to list databases: %s", err)
+	}
+	if len(databases) == 0 || databases[0] != "main" {
+		t.Fatalf("Expected main database, got %v", databases)
+	}
+	if err := driver.SelectDatabase("main"); err != nil {
+		t.Fatalf("Failed to select database: %s", err)
+	}
+
+	tables, err := driver.Tables()
+	if err != nil {
+		t.Fatalf("Failed to list tables: %s", err)
+	}
+	if len(tables) != 2 ||
+		tables[0].DisplayString() != "user_names" ||
+		tables[1].DisplayString() != "users" {
+		t.Fatalf("Incorrect tables `%#v`", tables)
+	}
+	if tables[0].Kind() != database.ObjectView || tables[1].Kind() != database.ObjectTable {
+		t.Fatalf("Incorrect kinds `%#v`", tables)
+	}
+
+	query := driver.QueryForTable(tables[1])
+	if query != "SELECT *\nFROM \"users\"" {
+		t.Fatalf("Incorrect query for table: %s", query)
+	}
+	results, err := driver.Query(query)
+	if err != nil {
+		t.Fatalf("Failed to query table: %s", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+	result := results[0]
+	if len(result.Rows) != 2 || result.HasMore {
+		t.Fatalf("Incorrect rows `%#v`", result.Rows)
+	}
+	if result.Columns[0].Kind != database.KindNumber ||
+		result.Columns[1].Kind != database.KindText ||
+		result.Columns[2].Kind != database.KindBinary {
+		t.Fatalf("Incorrect columns `%#v`", result.Columns)
+	}
+	if result.Rows[0][2].Value != "0xcafe" || !result.Rows[1][2].Null {
+		t.Fatalf("Incorrect cells `%#v`", result.Rows)
+	}
+}
+
+func TestExec(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	query := database.Query("INSERT INTO users (name) VALUES ('carol')")
+	if query.ReturnsRows() || !query.IsWrite() {
+		t.Fatalf("Insert should be a write that returns no rows")
+	}
+	result, err := driver.Exec(query)
+	if err != nil {
+		t.Fatalf("Failed to insert: %s", err)
+	}
+	if result.String() != "1 row affected, last insert id 3" {
+		t.Fatalf("Incorrect result for insert: %s", result)
+	}
+
+	result, err = driver.Exec("-- rename everyone\nUPDATE users SET name = 'dave'")
+	if err != nil {
+		t.Fatalf("Failed to update: %s", err)
+	}
+	if result.String() != "3 rows affected" {
+		t.Fatalf("Incorrect result for update: %s", result)
+	}
+}
+
+func TestParameters(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	// The value would break the statement if it was substituted into the query
+	query, args, err := database.Query("INSERT INTO users (name) VALUES (:name)").Bind(database.DialectSqlite, map[string]any{":name": "o'neil"})
+	if err != nil {
+		t.Fatalf("Failed to bind parameters: %s", err)
+	}
+	if _, err := driver.Exec(query, args...); err != nil {
+		t.Fatalf("Failed to insert with parameters: %s", err)
+	}
+
+	results, err := driver.Query("SELECT name FROM users WHERE name = ? OR id = ?", "o'neil", int64(1))
+	if err != nil {
+		t.Fatalf("Failed to query with parameters: %s", err)
+	}
+	rows := results[0].Rows
+	if len(rows) != 2 || rows[0][0].Value != "alice" || rows[1][0].Value != "o'neil" {
+		t.Fatalf("Incorrect rows `%#v`", rows)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	if progress := driver.Progress(); progress.Running || progress.Rows != 0 {
+		t.Fatalf("Expected no progress before the first query, got %#v", progress)
+	}
+	results, err := driver.Query("SELECT * FROM users")
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	progress := driver.Progress()
+	if progress.Running || progress.Rows != 2 {
+		t.Fatalf("Expected 2 rows fetched by a finished query, got %#v", progress)
+	}
+	if results[0].Duration <= 0 || results[0].Duration != progress.Elapsed {
+		t.Fatalf("Expected the duration of the query in the result, got %s and %s", results[0].Duration, progress.Elapsed)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if driver.Progress().Elapsed != progress.Elapsed {
+		t.Fatalf("Elapsed time should stop when the query finished")
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	if err := driver.Begin(); err != nil {
+		t.Fatalf("Failed to begin transaction: %s", err)
+	}
+	if _, err := driver.Exec("DELETE FROM users"); err != nil {
+		t.Fatalf("Failed to delete: %s", err)
+	}
+	results, err := driver.Query("SELECT count(*) FROM users")
+	if err != nil {
+		t.Fatalf("Failed to count: %s", err)
+	}
+	if results[0].Rows[0][0].Value != "0" {
+		t.Fatalf("Delete should be visible inside the transaction, got %s rows", results[0].Rows[0][0])
+	}
+	if open, statements := driver.Transaction(); !open || statements != 2 {
+		t.Fatalf("Expected open transaction with 2 statements, got %t %d", open, statements)
+	}
+	if err := driver.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %s", err)
+	}
+	if open, _ := driver.Transaction(); open {
+		t.Fatalf("Transaction should be closed after rollback")
+	}
+
+	results, err = driver.Query("SELECT count(*) FROM users")
+	if err != nil {
+		t.Fatalf("Failed to count: %s", err)
+	}
+	if results[0].Rows[0][0].Value != "2" {
+		t.Fatalf("Delete should be rolled back, got %s rows", results[0].Rows[0][0])
+	}
+}
+
+func TestFetchMore(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	query := database.Query(fmt.Sprintf(
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < %d) SELECT i FROM n",
+		database.PageSize+10,
+	))
+	results, err := driver.Query(query)
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	result := results[0]
+	if len(result.Rows) != database.PageSize || !result.HasMore {
+		t.Fatalf("Expected a full first page with more rows, got %d rows", len(result.Rows))
+	}
+	rows, hasMore, truncated, err := driver.FetchMore(result)
+	if err != nil {
+		t.Fatalf("Failed to fetch more: %s", err)
+	}
+	if len(rows) != 10 || hasMore || truncated {
+		t.Fatalf("Expected the last 10 rows, got %d rows (more: %t, truncated: %t)", len(rows), hasMore, truncated)
+	}
+
+	results, err = driver.Query(query)
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	if _, err := driver.Query("SELECT 1"); err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	rows, hasMore, truncated, err = driver.FetchMore(results[0])
+	if !errors.Is(err, database.ErrResultClosed) || len(rows) != 0 || hasMore || !truncated {
+		t.Fatalf("Starting a new query should truncate the previous result, got %d rows (more: %t, truncated: %t), %v", len(rows), hasMore, truncated, err)
+	}
+}
+
+func TestPaging(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	numbers := func(amount int) database.Query {
+		return database.Query(fmt.Sprintf(
+			"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < %d) SELECT i FROM n",
+			amount,
+		))
+	}
+
+	// A page that is exactly filled might have more rows, which only the next fetch can tell
+	results, err := driver.Query(numbers(database.PageSize))
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	if len(results[0].Rows) != database.PageSize || !results[0].HasMore {
+		t.Fatalf("Expected a full first page with more rows, got %d rows (more: %t)", len(results[0].Rows), results[0].HasMore)
+	}
+	rows, hasMore, truncated, err := driver.FetchMore(results[0])
+	if err != nil || len(rows) != 0 || hasMore || truncated {
+		t.Fatalf("Expected no more rows, got %d rows (more: %t, truncated: %t), %v", len(rows), hasMore, truncated, err)
+	}
+
+	// Fetching until there are no more rows returns all of them in order
+	results, err = driver.Query(numbers(2*database.PageSize + 5))
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	all := results[0].Rows
+	for hasMore = results[0].HasMore; hasMore; {
+		rows, hasMore, truncated, err = driver.FetchMore(results[0])
+		if err != nil || truncated {
+			t.Fatalf("Failed to fetch more after %d rows: %v (truncated: %t)", len(all), err, truncated)
+		}
+		all = append(all, rows...)
+	}
+	if len(all) != 2*database.PageSize+5 {
+		t.Fatalf("Expected %d rows, got %d", 2*database.PageSize+5, len(all))
+	}
+	for i, row := range all {
+		if row[0].Value != fmt.Sprint(i+1) {
+			t.Fatalf("Expected row %d to be %d, got %s", i, i+1, row[0].Value)
+		}
+	}
+	if _, _, _, err := driver.FetchMore(results[0]); !errors.Is(err, database.ErrResultClosed) {
+		t.Fatalf("Expected the drained result to be closed, got %v", err)
+	}
+
+	// A new query closes the rows of the open result
+	results, err = driver.Query(numbers(database.PageSize + 1))
+	if err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	if _, err := driver.Exec("UPDATE users SET name = 'erin' WHERE id = 1"); err != nil {
+		t.Fatalf("Failed to update: %s", err)
+	}
+	rows, hasMore, truncated, err = driver.FetchMore(results[0])
+	if !errors.Is(err, database.ErrResultClosed) || len(rows) != 0 || hasMore || !truncated {
+		t.Fatalf("Expected the result to be closed by the new statement, got %d rows (more: %t, truncated: %t), %v", len(rows), hasMore, truncated, err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	_, err = driver.Exec(`CREATE TABLE posts (
+		user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+		slug TEXT NOT NULL,
+		title TEXT DEFAULT 'untitled',
+		PRIMARY KEY (user_id, slug)
+	)`)
+	if err != nil {
+		t.Fatalf("Failed to create table: %s", err)
+	}
+	if _, err := driver.Exec("CREATE UNIQUE INDEX posts_title ON posts (title, lower(slug))"); err != nil {
+		t.Fatalf("Failed to create index: %s", err)
+	}
+
+	structure, err := driver.Describe(sqliteTable{schema: "main", name: "posts", kind: database.ObjectTable})
+	if err != nil {
+		t.Fatalf("Failed to describe table: %s", err)
+	}
+	if len(structure.Columns) != 3 {
+		t.Fatalf("Incorrect columns `%#v`", structure.Columns)
+	}
+	title := structure.Columns[2]
+	if title.Type != "TEXT" || !title.Nullable || !title.HasDefault || title.Default != "'untitled'" {
+		t.Fatalf("Incorrect title column `%#v`", title)
+	}
+	if structure.Columns[0].Nullable || structure.Columns[0].HasDefault {
+		t.Fatalf("Incorrect user_id column `%#v`", structure.Columns[0])
+	}
+	if len(structure.PrimaryKey) != 2 || structure.PrimaryKey[0] != "user_id" || structure.PrimaryKey[1] != "slug" {
+		t.Fatalf("Incorrect primary key `%#v`", structure.PrimaryKey)
+	}
+	if len(structure.Indexes) != 2 || !structure.Indexes[0].Primary {
+		t.Fatalf("Incorrect indexes `%#v`", structure.Indexes)
+	}
+	index := structure.Indexes[1]
+	if index.Name != "posts_title" || !index.Unique || len(index.Columns) != 2 ||
+		index.Columns[0] != "title" || index.Columns[1] != "(expression)" {
+		t.Fatalf("Incorrect index `%#v`", index)
+	}
+	if len(structure.ForeignKeys) != 1 {
+		t.Fatalf("Incorrect foreign keys `%#v`", structure.ForeignKeys)
+	}
+	key := structure.ForeignKeys[0]
+	if key.ReferencedTable != "users" || key.Columns[0] != "user_id" ||
+		key.ReferencedColumns[0] != "id" || key.OnDelete != "CASCADE" {
+		t.Fatalf("Incorrect foreign key `%#v`", key)
+	}
+
+	structure, err = driver.Describe(sqliteTable{schema: "main", name: "users"})
+	if err != nil {
+		t.Fatalf("Failed to describe table: %s", err)
+	}
+	if len(structure.PrimaryKey) != 1 || len(structure.Indexes) != 0 {
+		t.Fatalf("Integer primary key should be known without an index `%#v`", structure)
+	}
+}
+
+func TestDDL(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	if _, err := driver.Exec("CREATE INDEX users_name ON users (name)"); err != nil {
+		t.Fatalf("Failed to create index: %s", err)
+	}
+	ddl, err := driver.DDL(sqliteTable{schema: "main", name: "users", kind: database.ObjectTable})
+	if err != nil {
+		t.Fatalf("Failed to get DDL: %s", err)
+	}
+	expected := "CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, avatar BLOB);\n\n" +
+		"CREATE INDEX users_name ON users (name);\n"
+	if ddl != expected {
+		t.Fatalf("Incorrect DDL:\n%s", ddl)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	plan, err := driver.Explain("SELECT * FROM users WHERE id = 1", false)
+	if err != nil {
+		t.Fatalf("Failed to explain query: %s", err)
+	}
+	if len(plan.Root.Children) != 1 {
+		t.Fatalf("Incorrect plan `%#v`", plan.Root)
+	}
+	if node := plan.Root.Children[0]; node.Operation != "SEARCH" || !strings.HasPrefix(node.Detail, "users") {
+		t.Fatalf("Incorrect node `%#v`", node)
+	}
+
+	if _, err := driver.Explain("SELECT * FROM users", true); err == nil {
+		t.Fatalf("Expected an error when analyzing")
+	}
+}
+
+func TestTransactionAborted(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	if err := driver.Begin(); err != nil {
+		t.Fatalf("Failed to begin transaction: %s", err)
+	}
+	// Drivers like pgx close the connection when a running statement is cancelled
+	base := &driver.(*sqliteDriver).BaseDriver
+	base.ExecConn = func(ctx context.Context, conn *sql.Conn, query database.Query, args []any) (*database.ExecResult, error) {
+		conn.Raw(func(any) error { return sqldriver.ErrBadConn })
+		return nil, context.Canceled
+	}
+	_, err = driver.Exec("DELETE FROM users")
+	if !errors.Is(err, database.ErrTransactionAborted) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the transaction to be aborted, got %v", err)
+	}
+	if open, _ := driver.Transaction(); open {
+		t.Fatalf("Transaction should be closed once its connection is gone")
+	}
+
+	base.ExecConn = nil
+	results, err := driver.Query("SELECT count(*) FROM users")
+	if err != nil {
+		t.Fatalf("Failed to count: %s", err)
+	}
+	if results[0].Rows[0][0].Value != "2" {
+		t.Fatalf("Nothing should have been deleted, got %s rows", results[0].Rows[0][0])
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{
+		File:         createTestDatabase(t),
+		QueryTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	slow := database.Query(`
+		WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 1000000000)
+		SELECT count(*) FROM counter`)
+	_, err = driver.Query(slow)
+	var timeoutErr *database.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("Expected a timeout error, got %v", err)
+	}
+	if err.Error() != "query timed out after 50ms" {
+		t.Fatalf("Unexpected message: %s", err)
+	}
+
+	// The next query runs normally again
+	if _, err := driver.Query("SELECT name FROM users"); err != nil {
+		t.Fatalf("Query after timeout failed: %s", err)
+	}
+
+	driver.WithoutTimeout()
+	results, err := driver.Query(`
+		WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 300000)
+		SELECT count(*) FROM counter`)
+	if err != nil {
+		t.Fatalf("Query without timeout failed: %s", err)
+	}
+	if results[0].Rows[0][0].Value != "300000" {
+		t.Fatalf("Unexpected count: %s", results[0].Rows[0][0].Value)
+	}
+}
+
+func TestQueryTimeoutInTransaction(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{
+		File:         createTestDatabase(t),
+		QueryTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	// Sqlite has no server, so pretend it has a statement timeout to check it is used instead of the client timer
+	serverTimeouts := make(chan time.Duration, 10)
+	base := &driver.(*sqliteDriver).BaseDriver
+	base.ServerTimeout = func(ctx context.Context, conn *sql.Conn, timeout time.Duration) error {
+		serverTimeouts <- timeout
+		return nil
+	}
+
+	if err := driver.Begin(); err != nil {
+		t.Fatalf("Failed to begin transaction: %s", err)
+	}
+	results, err := driver.Query(`
+		WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 1000000)
+		SELECT count(*) FROM counter`)
+	if err != nil {
+		t.Fatalf("The client should not cancel a statement in a transaction, got %v", err)
+	}
+	if results[0].Rows[0][0].Value != "1000000" {
+		t.Fatalf("Unexpected count: %s", results[0].Rows[0][0].Value)
+	}
+	if timeout := <-serverTimeouts; timeout != 50*time.Millisecond {
+		t.Fatalf("Server timeout should be the query timeout, got %s", timeout)
+	}
+	if _, err := driver.Exec("DELETE FROM users WHERE id = 1"); err != nil {
+		t.Fatalf("Transaction should still be usable: %s", err)
+	}
+	if err := driver.Commit(); err != nil {
+		t.Fatalf("Failed to commit: %s", err)
+	}
+}
+
+func TestCancelQuery(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	if outcome, err := driver.CancelQuery(); outcome != database.CancelNothingRunning || err != nil {
+		t.Fatalf("Expected nothing to cancel, got %d, %v", outcome, err)
+	}
+
+	// Sqlite has no server, so pretend it has one to check the server is asked first
+	cancelled := make(chan int64, 1)
+	started := make(chan struct{})
+	base := &driver.(*sqliteDriver).BaseDriver
+	base.ConnectionID = func(ctx context.Context, conn *sql.Conn) (int64, error) {
+		close(started)
+		return 42, nil
+	}
+	base.CancelOnServer = func(ctx context.Context, db *sql.DB, connectionID int64) (bool, error) {
+		cancelled <- connectionID
+		return true, nil
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := driver.Query(`
+			WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 1000000000)
+			SELECT count(*) FROM counter`)
+		done <- err
+	}()
+	<-started
+	// Give the driver time to remember the connection and start the query
+	time.Sleep(50 * time.Millisecond)
+	outcome, err := driver.CancelQuery()
+	if outcome != database.CancelConfirmed || err != nil {
+		t.Fatalf("Expected a confirmed cancel, got %d, %v", outcome, err)
+	}
+	if id := <-cancelled; id != 42 {
+		t.Fatalf("Server was asked to cancel connection %d", id)
+	}
+	if err := <-done; err == nil {
+		t.Fatalf("Expected the cancelled query to fail")
+	}
+}
+
+func TestCancelQueryInTransaction(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	// Sqlite has no server, so pretend it has one that confirms the cancel
+	cancelled := make(chan int64, 1)
+	started := make(chan struct{}, 1)
+	base := &driver.(*sqliteDriver).BaseDriver
+	base.ConnectionID = func(ctx context.Context, conn *sql.Conn) (int64, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return 42, nil
+	}
+	base.CancelOnServer = func(ctx context.Context, db *sql.DB, connectionID int64) (bool, error) {
+		cancelled <- connectionID
+		return true, nil
+	}
+
+	if err := driver.Begin(); err != nil {
+		t.Fatalf("Failed to begin transaction: %s", err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := driver.Query(`
+			WITH RECURSIVE counter(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM counter WHERE n < 1000000)
+			SELECT count(*) FROM counter`)
+		done <- err
+	}()
+	<-started
+	time.Sleep(50 * time.Millisecond)
+	outcome, err := driver.CancelQuery()
+	if outcome != database.CancelConfirmed || err != nil {
+		t.Fatalf("Expected a confirmed cancel, got %d, %v", outcome, err)
+	}
+	if id := <-cancelled; id != 42 {
+		t.Fatalf("Server was asked to cancel connection %d", id)
+	}
+	// The pretend server does not stop the statement, so it only finishes when the client kept waiting
+	if err := <-done; err != nil {
+		t.Fatalf("The client should not cancel a statement in a transaction, got %v", err)
+	}
+
+	if open, _ := driver.Transaction(); !open {
+		t.Fatalf("Transaction should still be open after cancelling")
+	}
+	if _, err := driver.Exec("DELETE FROM users WHERE id = 1"); err != nil {
+		t.Fatalf("Transaction should still be usable: %s", err)
+	}
+	if err := driver.Rollback(); err != nil {
+		t.Fatalf("Failed to rollback: %s", err)
+	}
+	results, err := driver.Query("SELECT count(*) FROM users")
+	if err != nil {
+		t.Fatalf("Failed to count: %s", err)
+	}
+	if results[0].Rows[0][0].Value != "2" {
+		t.Fatalf("Delete should be rolled back, got %s rows", results[0].Rows[0][0])
+	}
+}
+
+func TestMessages(t *testing.T) {
+	driver, err := NewSqliteDriver(database.Dsn{File: createTestDatabase(t)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %s", err)
+	}
+	defer driver.Close()
+
+	// Sqlite has no warnings, so pretend it has them like MySQL
+	base := &driver.(*sqliteDriver).BaseDriver
+	base.Warnings = func(ctx context.Context, conn *sql.Conn) ([]database.Message, error) {
+		return []database.Message{{Severity: "Warning", Code: "1265", Text: "Data truncated for column 'name' at row 1"}}, nil
+	}
+
+	if _, err := driver.Exec("UPDATE users SET name = 'carol'"); err != nil {
+		t.Fatalf("Failed to update: %s", err)
+	}
+	messages := driver.Messages()
+	if len(messages) != 1 || messages[0].String() != "[Warning 1265]: Data truncated for column 'name' at row 1" {
+		t.Fatalf("Expected the warning of the update, got %v", messages)
+	}
+
+	base.Warnings = nil
+	base.AddMessage(database.Message{Severity: "NOTICE", Text: "stale"})
+	if _, err := driver.Query("SELECT * FROM users"); err != nil {
+		t.Fatalf("Failed to query: %s", err)
+	}
+	if messages := driver.Messages(); len(messages) != 0 {
+		t.Fatalf("Expected the messages to be cleared by the next query, got %v", messages)
+	}
+}
@@ -0,0 +1,43 @@
+package sqlitedriver
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// Explain implements Driver.
+// sqlite only reports which steps the plan consists of, without any estimates
+func (m *sqliteDriver) Explain(query database.Query, analyze bool) (*database.Plan, error) {
+	if analyze {
+		return nil, errors.New("sqlite cannot analyze queries, use EXPLAIN instead")
+	}
+	results, err := m.Query(database.Query("EXPLAIN QUERY PLAN " + string(query)))
+	if err != nil {
+		return nil, err
+	}
+	root := &database.PlanNode{Operation: "QUERY PLAN"}
+	nodes := map[string]*database.PlanNode{}
+	for _, row := range results[0].Rows {
+		if len(row) < 4 {
+			return nil, errors.New("failed to parse plan: unexpected columns")
+		}
+		id, parentId, detail := row[0].Value, row[1].Value, row[3].Value
+		node := &database.PlanNode{Operation: detail}
+		for _, operation := range []string{"SCAN ", "SEARCH "} {
+			if strings.HasPrefix(detail, operation) {
+				node.Operation = strings.TrimSpace(operation)
+				node.Detail = strings.TrimPrefix(detail, operation)
+			}
+		}
+		// Parents are always listed before their children
+		parent, ok := nodes[parentId]
+		if !ok {
+			parent = root
+		}
+		parent.Children = append(parent.Children, node)
+		nodes[id] = node
+	}
+	return &database.Plan{Root: root}, nil
+}
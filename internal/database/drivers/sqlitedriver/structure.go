@@ -0,0 +1,103 @@
+package sqlitedriver
+
+import (
+	"database/sql"
+
+	"github.com/Kavantix/lazysql/internal/database"
+)
+
+// Describe implements Driver.
+func (m *sqliteDriver) Describe(dbTable database.Table) (*database.TableStructure, error) {
+	table := dbTable.(sqliteTable)
+	structure := &database.TableStructure{}
+	if err := m.describeColumns(structure, table); err != nil {
+		return nil, err
+	}
+	if err := m.describeIndexes(structure, table); err != nil {
+		return nil, err
+	}
+	if err := m.describeForeignKeys(structure, table); err != nil {
+		return nil, err
+	}
+	return structure, nil
+}
+
+func (m *sqliteDriver) describeColumns(structure *database.TableStructure, table sqliteTable) error {
+	rows, err := m.Db.Query(`SELECT name, type, NOT "notnull", dflt_value, pk FROM pragma_table_info(?, ?) ORDER BY cid`, table.name, table.schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	primaryKey := map[int]string{}
+	for rows.Next() {
+		column := database.ColumnDefinition{}
+		var columnDefault sql.NullString
+		var primaryKeyPosition int
+		if err := rows.Scan(&column.Name, &column.Type, &column.Nullable, &columnDefault, &primaryKeyPosition); err != nil {
+			return err
+		}
+		column.Default, column.HasDefault = columnDefault.String, columnDefault.Valid
+		if primaryKeyPosition > 0 {
+			primaryKey[primaryKeyPosition] = column.Name
+		}
+		structure.Columns = append(structure.Columns, column)
+	}
+	for i := 1; i <= len(primaryKey); i++ {
+		structure.PrimaryKey = append(structure.PrimaryKey, primaryKey[i])
+	}
+	return rows.Err()
+}
+
+func (m *sqliteDriver) describeIndexes(structure *database.TableStructure, table sqliteTable) error {
+	rows, err := m.Db.Query(`
+		SELECT l.name, l."unique", l.origin = 'pk', coalesce(i.name, '(expression)')
+		FROM pragma_index_list(?, ?) l
+		JOIN pragma_index_info(l.name, ?) i
+		ORDER BY l.origin = 'pk' DESC, l.name, i.seqno`, table.name, table.schema, table.schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, column string
+		var unique, primary bool
+		if err := rows.Scan(&name, &unique, &primary, &column); err != nil {
+			return err
+		}
+		// The primary key is already known from the columns,
+		// which also covers an INTEGER PRIMARY KEY that has no index
+		structure.AddIndexColumn(name, unique, false, column)
+		structure.Indexes[len(structure.Indexes)-1].Primary = primary
+	}
+	return rows.Err()
+}
+
+func (m *sqliteDriver) describeForeignKeys(structure *database.TableStructure, table sqliteTable) error {
+	rows, err := m.Db.Query(`
+		SELECT id, "table", "from", "to", on_update, on_delete
+		FROM pragma_foreign_key_list(?, ?)
+		ORDER BY id, seq`, table.name, table.schema)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	lastId := -1
+	for rows.Next() {
+		var id int
+		var column string
+		// Without a column the primary key of the referenced table is referenced
+		var referencedColumn sql.NullString
+		key := database.ForeignKey{}
+		if err := rows.Scan(&id, &key.ReferencedTable, &column, &referencedColumn, &key.OnUpdate, &key.OnDelete); err != nil {
+			return err
+		}
+		if id != lastId {
+			structure.ForeignKeys = append(structure.ForeignKeys, key)
+			lastId = id
+		}
+		foreignKey := &structure.ForeignKeys[len(structure.ForeignKeys)-1]
+		foreignKey.Columns = append(foreignKey.Columns, column)
+		foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, referencedColumn.String)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxBinaryDisplayBytes limits how much of a binary value is converted to hex
+const maxBinaryDisplayBytes = 1024
+
+func formatCell(value any, column Column) Cell {
+	switch value := value.(type) {
+	case nil:
+		return Cell{Null: true}
+	case []byte:
+		if column.Kind == KindBinary || !utf8.Valid(value) {
+			return Cell{Value: formatBinary(value)}
+		}
+		return Cell{Value: strings.ReplaceAll(string(value), "\r", "")}
+	case string:
+		return Cell{Value: strings.ReplaceAll(value, "\r", "")}
+	case time.Time:
+		return Cell{Value: formatTime(value, column.DatabaseType)}
+	case int64:
+		return Cell{Value: strconv.FormatInt(value, 10)}
+	case uint64:
+		return Cell{Value: strconv.FormatUint(value, 10)}
+	case float64:
+		return Cell{Value: strconv.FormatFloat(value, 'f', -1, 64)}
+	case bool:
+		return Cell{Value: strconv.FormatBool(value)}
+	default:
+		return Cell{Value: fmt.Sprint(value)}
+	}
+}
+
+func formatBinary(value []byte) string {
+	if len(value) > maxBinaryDisplayBytes {
+		return fmt.Sprintf("0x%s… (%d bytes)", hex.EncodeToString(value[:maxBinaryDisplayBytes]), len(value))
+	}
+	return "0x" + hex.EncodeToString(value)
+}
+
+func formatTime(value time.Time, databaseType string) string {
+	switch databaseType {
+	case "DATE":
+		return value.Format(time.DateOnly)
+	case "TIME":
+		return value.Format("15:04:05.999999")
+	case "TIMESTAMPTZ":
+		return value.Format("2006-01-02 15:04:05.999999-07:00")
+	default:
+		return value.Format("2006-01-02 15:04:05.999999")
+	}
+}
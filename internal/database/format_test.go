@@ -0,0 +1,122 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatCell(t *testing.T) {
+	at := time.Date(2024, 3, 7, 14, 5, 9, 120000000, time.FixedZone("", 2*60*60))
+	tests := []struct {
+		name     string
+		value    any
+		column   Column
+		expected Cell
+	}{
+		{
+			name:     "null",
+			value:    nil,
+			column:   Column{Kind: KindText},
+			expected: Cell{Null: true},
+		},
+		{
+			name:     "null number",
+			value:    nil,
+			column:   Column{Kind: KindNumber},
+			expected: Cell{Null: true},
+		},
+		{
+			name:     "empty string is not null",
+			value:    "",
+			column:   Column{Kind: KindText},
+			expected: Cell{},
+		},
+		{
+			name:     "text bytes",
+			value:    []byte("hello\r\nworld"),
+			column:   Column{Kind: KindText},
+			expected: Cell{Value: "hello\nworld"},
+		},
+		{
+			name:     "binary column",
+			value:    []byte("abc"),
+			column:   Column{Kind: KindBinary},
+			expected: Cell{Value: "0x616263"},
+		},
+		{
+			name:     "invalid utf-8 bytes",
+			value:    []byte{0xff, 0x00, 0x10},
+			column:   Column{Kind: KindText},
+			expected: Cell{Value: "0xff0010"},
+		},
+		{
+			name:     "large binary",
+			value:    make([]byte, maxBinaryDisplayBytes+1),
+			column:   Column{Kind: KindBinary},
+			expected: Cell{Value: "0x" + strings.Repeat("00", maxBinaryDisplayBytes) + "… (1025 bytes)"},
+		},
+		{
+			name:     "date",
+			value:    at,
+			column:   Column{DatabaseType: "DATE", Kind: KindTime},
+			expected: Cell{Value: "2024-03-07"},
+		},
+		{
+			name:     "time",
+			value:    at,
+			column:   Column{DatabaseType: "TIME", Kind: KindTime},
+			expected: Cell{Value: "14:05:09.12"},
+		},
+		{
+			name:     "timestamp with time zone",
+			value:    at,
+			column:   Column{DatabaseType: "TIMESTAMPTZ", Kind: KindTime},
+			expected: Cell{Value: "2024-03-07 14:05:09.12+02:00"},
+		},
+		{
+			name:     "timestamp",
+			value:    at,
+			column:   Column{DatabaseType: "DATETIME", Kind: KindTime},
+			expected: Cell{Value: "2024-03-07 14:05:09.12"},
+		},
+		{
+			name:     "true",
+			value:    true,
+			column:   Column{Kind: KindBool},
+			expected: Cell{Value: "true"},
+		},
+		{
+			name:     "false",
+			value:    false,
+			column:   Column{Kind: KindBool},
+			expected: Cell{Value: "false"},
+		},
+		{
+			name:     "integer",
+			value:    int64(-42),
+			column:   Column{Kind: KindNumber},
+			expected: Cell{Value: "-42"},
+		},
+		{
+			name:     "unsigned integer",
+			value:    uint64(18446744073709551615),
+			column:   Column{Kind: KindNumber},
+			expected: Cell{Value: "18446744073709551615"},
+		},
+		{
+			name:     "float",
+			value:    1.5,
+			column:   Column{Kind: KindNumber},
+			expected: Cell{Value: "1.5"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cell := formatCell(test.value, test.column)
+			if cell != test.expected {
+				t.Fatalf("Expected %#v, got %#v", test.expected, cell)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package database
+
+import "time"
+
+// Notification is a payload that was sent to a channel that is listened to
+type Notification struct {
+	// At is when the notification was received
+	At      time.Time
+	Channel string
+	Payload string
+	// PID is the server process of the session that sent the notification
+	PID uint32
+}
+
+// Listener is implemented by drivers of databases that can send notifications between sessions,
+// like LISTEN and NOTIFY in Postgres
+type Listener interface {
+	// Subscribe opens a dedicated connection to listen to channels on
+	// onNotification is called for every notification received until the subscription is closed
+	// onError is called when the connection fails, after which no notifications are received
+	Subscribe(onNotification func(Notification), onError func(error)) (Subscription, error)
+
+	// Notify sends payload to the sessions that listen to channel
+	Notify(channel, payload string) error
+}
+
+// Subscription is a connection that receives the notifications of the channels it listens to
+type Subscription interface {
+	// Listen starts receiving the notifications sent to channel
+	Listen(channel string) error
+
+	// Unlisten stops receiving the notifications sent to channel
+	Unlisten(channel string) error
+
+	// Channels returns the channels that are listened to
+	Channels() []string
+
+	// Close stops listening and closes the connection
+	Close() error
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Message is a notice or warning the server sent while executing a statement
+type Message struct {
+	// Severity is how the server classified the message, like NOTICE or Warning
+	Severity string
+	// Code is the error code of the message, empty when the server has none
+	Code string
+	Text string
+}
+
+func (m Message) String() string {
+	if m.Code == "" {
+		return fmt.Sprintf("[%s]: %s", m.Severity, m.Text)
+	}
+	return fmt.Sprintf("[%s %s]: %s", m.Severity, m.Code, m.Text)
+}
+
+// AddMessage records a message the server sent for the running query,
+// drivers that are notified of messages asynchronously call it from their handler
+func (b *BaseDriver) AddMessage(message Message) {
+	b.messagesMutex.Lock()
+	defer b.messagesMutex.Unlock()
+	b.messages = append(b.messages, message)
+}
+
+// Messages returns the notices and warnings the server sent during the last query
+func (b *BaseDriver) Messages() []Message {
+	b.messagesMutex.Lock()
+	defer b.messagesMutex.Unlock()
+	return append([]Message(nil), b.messages...)
+}
+
+// clearMessages forgets the messages of the previous query
+func (b *BaseDriver) clearMessages() {
+	b.messagesMutex.Lock()
+	defer b.messagesMutex.Unlock()
+	b.messages = nil
+}
+
+// collectWarnings asks the server for the warnings of the statement that was just executed on conn
+// for drivers that do not receive them on their own
+// Failing to get them is not an error of the statement so it is recorded as a message too
+func (b *BaseDriver) collectWarnings(ctx context.Context, conn *sql.Conn) {
+	if b.Warnings == nil {
+		return
+	}
+	warnings, err := b.Warnings(ctx, conn)
+	if err != nil {
+		b.AddMessage(Message{Severity: "Error", Text: "failed to get warnings: " + err.Error()})
+		return
+	}
+	for _, warning := range warnings {
+		b.AddMessage(warning)
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+// ObjectKind is the kind of database object listed by Driver.Tables
+type ObjectKind uint8
+
+const (
+	ObjectTable ObjectKind = iota
+	ObjectView
+	ObjectMaterializedView
+	ObjectSequence
+	ObjectFunction
+	ObjectProcedure
+)
+
+// ObjectKinds contains every ObjectKind in the order they are presented
+var ObjectKinds = []ObjectKind{
+	ObjectTable,
+	ObjectView,
+	ObjectMaterializedView,
+	ObjectSequence,
+	ObjectFunction,
+	ObjectProcedure,
+}
+
+func (k ObjectKind) String() string {
+	switch k {
+	case ObjectTable:
+		return "table"
+	case ObjectView:
+		return "view"
+	case ObjectMaterializedView:
+		return "materialized view"
+	case ObjectSequence:
+		return "sequence"
+	case ObjectFunction:
+		return "function"
+	case ObjectProcedure:
+		return "procedure"
+	default:
+		return "unknown"
+	}
+}
+
+// Marker is a single character that identifies the kind in lists
+func (k ObjectKind) Marker() rune {
+	switch k {
+	case ObjectTable:
+		return 't'
+	case ObjectView:
+		return 'v'
+	case ObjectMaterializedView:
+		return 'm'
+	case ObjectSequence:
+		return 's'
+	case ObjectFunction:
+		return 'f'
+	case ObjectProcedure:
+		return 'p'
+	default:
+		return '?'
+	}
+}
+
+// IsRoutine reports whether objects of this kind are code rather than data
+func (k ObjectKind) IsRoutine() bool {
+	return k == ObjectFunction || k == ObjectProcedure
+}
@@ -0,0 +1,230 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Placeholder is a marker in a query for a parameter that is bound when executing it
+type Placeholder struct {
+	// Name identifies the parameter, like $1 or :name, every ? is a separate parameter named by its position like ?1
+	Name string
+	// Start and End are the byte offsets of the placeholder in the query
+	Start, End int
+}
+
+// Placeholders returns the placeholders in q, skipping quoted strings and comments
+// Postgres uses $1 and :name, where ? is an operator, mysql and sqlite use ? and :name
+func (q Query) Placeholders(dialect Dialect) []Placeholder {
+	script := string(q)
+	placeholders := []Placeholder{}
+	add := func(name string, start, end int) {
+		placeholders = append(placeholders, Placeholder{Name: name, Start: start, End: end})
+	}
+	questionMarks := 0
+	// brackets is how deeply the current position is nested in postgres array subscripts
+	brackets := 0
+	for i := 0; i < len(script); {
+		c := script[i]
+		switch {
+		case c == '\'' || c == '"' || (c == '`' && dialect != DialectPostgres):
+			i = skipQuoted(script, i, dialect == DialectMysql)
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"),
+			c == '#' && dialect == DialectMysql:
+			i = skipLine(script, i)
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = skipBlockComment(script, i, dialect == DialectPostgres)
+			continue
+		case c == '$' && dialect == DialectPostgres:
+			end := i + 1
+			for end < len(script) && isDigit(script[end]) {
+				end++
+			}
+			if end > i+1 {
+				add(script[i:end], i, end)
+				i = end
+				continue
+			}
+			if end, ok := skipDollarQuoted(script, i); ok {
+				i = end
+				continue
+			}
+		case c == '[' && dialect == DialectPostgres:
+			brackets++
+		case c == ']' && brackets > 0:
+			brackets--
+		case c == '?' && dialect != DialectPostgres:
+			questionMarks++
+			add(fmt.Sprintf("?%d", questionMarks), i, i+1)
+		case c == ':' && i+1 < len(script) && isNameStart(script[i+1]) && (i == 0 || script[i-1] != ':') &&
+			!(brackets > 0 && isSliceBound(script, i)):
+			end := i + 1
+			for end < len(script) && isWordByte(script[end]) {
+				end++
+			}
+			add(script[i:end], i, end)
+			i = end
+			continue
+		case isWordByte(c):
+			// Identifiers can contain $ in postgres, which should not start a placeholder
+			end := i
+			for end < len(script) && (isWordByte(script[end]) || script[end] == '$') {
+				end++
+			}
+			i = end
+			continue
+		}
+		i++
+	}
+	return placeholders
+}
+
+// isSliceBound reports whether the : at i separates the bounds of an array slice like arr[lo:hi] or arr[:hi],
+// which is the case when it follows the [ or an identifier or number
+func isSliceBound(script string, i int) bool {
+	before := strings.TrimRight(script[:i], " \t\n\r")
+	return before != "" && (before[len(before)-1] == '[' || isWordByte(before[len(before)-1]))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// Parameters returns the names of the distinct parameters of q in the order they are bound
+// Numbered parameters are sorted by their number, named ones are in order of appearance
+// Returns an error when q mixes named and positional placeholders
+func (q Query) Parameters(dialect Dialect) ([]string, error) {
+	placeholders := q.Placeholders(dialect)
+	if err := checkPlaceholderStyle(placeholders); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	if len(placeholders) > 0 && placeholders[0].Name[0] == '$' {
+		highest := 0
+		for _, placeholder := range placeholders {
+			number, _ := strconv.Atoi(placeholder.Name[1:])
+			highest = max(highest, number)
+		}
+		for number := 1; number <= highest; number++ {
+			names = append(names, fmt.Sprintf("$%d", number))
+		}
+		return names, nil
+	}
+	seen := map[string]bool{}
+	for _, placeholder := range placeholders {
+		if !seen[placeholder.Name] {
+			seen[placeholder.Name] = true
+			names = append(names, placeholder.Name)
+		}
+	}
+	return names, nil
+}
+
+func checkPlaceholderStyle(placeholders []Placeholder) error {
+	for _, placeholder := range placeholders[min(1, len(placeholders)):] {
+		if placeholder.Name[0] != placeholders[0].Name[0] {
+			return fmt.Errorf("cannot mix %s and %s placeholders in one query", placeholders[0].Name, placeholder.Name)
+		}
+	}
+	return nil
+}
+
+// Bind returns the query and arguments to execute q with values as its parameters,
+// values contains a value for every name returned by Parameters
+// Named placeholders are rewritten to the positional placeholders of dialect
+// since only sqlite supports named parameters, the values are never put in the query itself
+func (q Query) Bind(dialect Dialect, values map[string]any) (Query, []any, error) {
+	names, err := q.Parameters(dialect)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			return "", nil, fmt.Errorf("no value for parameter %s", name)
+		}
+	}
+	if len(names) == 0 {
+		return q, nil, nil
+	}
+	args := []any{}
+	if names[0][0] != ':' {
+		for _, name := range names {
+			args = append(args, values[name])
+		}
+		return q, args, nil
+	}
+
+	builder := strings.Builder{}
+	previousEnd := 0
+	numbers := map[string]int{}
+	for _, placeholder := range q.Placeholders(dialect) {
+		builder.WriteString(string(q[previousEnd:placeholder.Start]))
+		previousEnd = placeholder.End
+		if dialect != DialectPostgres {
+			builder.WriteByte('?')
+			args = append(args, values[placeholder.Name])
+			continue
+		}
+		number, ok := numbers[placeholder.Name]
+		if !ok {
+			args = append(args, values[placeholder.Name])
+			number = len(args)
+			numbers[placeholder.Name] = number
+		}
+		builder.WriteString(fmt.Sprintf("$%d", number))
+	}
+	builder.WriteString(string(q[previousEnd:]))
+	return Query(builder.String()), args, nil
+}
+
+// ParamType is the type the value of a parameter is converted to before it is bound
+type ParamType string
+
+const (
+	ParamText    ParamType = "text"
+	ParamInteger ParamType = "integer"
+	ParamNumber  ParamType = "number"
+	ParamBoolean ParamType = "boolean"
+	ParamNull    ParamType = "null"
+)
+
+// ParamTypes are all types a parameter can have
+var ParamTypes = []ParamType{ParamText, ParamInteger, ParamNumber, ParamBoolean, ParamNull}
+
+// Convert converts value to the type of parameter t
+func (t ParamType) Convert(value string) (any, error) {
+	switch t {
+	case ParamText:
+		return value, nil
+	case ParamInteger:
+		number, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not an integer", value)
+		}
+		return number, nil
+	case ParamNumber:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a number", value)
+		}
+		return number, nil
+	case ParamBoolean:
+		boolean, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a boolean, use true or false", value)
+		}
+		return boolean, nil
+	case ParamNull:
+		return nil, nil
+	default:
+		return nil, errors.New("unknown parameter type " + string(t))
+	}
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		query    Query
+		expected []string
+	}{
+		{
+			name:     "numbered",
+			dialect:  DialectPostgres,
+			query:    "SELECT * FROM users WHERE id = $2 AND name = $1 OR id = $2",
+			expected: []string{"$1", "$2"},
+		},
+		{
+			name:     "casts, operators and strings are not placeholders",
+			dialect:  DialectPostgres,
+			query:    "SELECT '$1 :name', data ? 'key', id::text, $$ :body $$, price$1 FROM t -- :comment\nWHERE a = :a",
+			expected: []string{":a"},
+		},
+		{
+			name:     "question marks",
+			dialect:  DialectMysql,
+			query:    "SELECT * FROM users WHERE id = ? AND name = '?' AND email = ? # ?",
+			expected: []string{"?1", "?2"},
+		},
+		{
+			name:     "named",
+			dialect:  DialectSqlite,
+			query:    "SELECT * FROM users WHERE name = :name OR nick = :name AND age > :min_age",
+			expected: []string{":name", ":min_age"},
+		},
+		{
+			name:     "array slices",
+			dialect:  DialectPostgres,
+			query:    "SELECT arr[lo:hi], arr[1:n], arr[:n], arr[2 : n], arr[lo:] FROM t WHERE arr[:idx] = :value",
+			expected: []string{":value"},
+		},
+		{
+			name:     "numbered parameters as slice bounds",
+			dialect:  DialectPostgres,
+			query:    "SELECT arr[$1:$2], arr[:i] FROM t",
+			expected: []string{"$1", "$2"},
+		},
+		{
+			name:     "no parameters",
+			dialect:  DialectMysql,
+			query:    "SELECT @a := 1, '12:30'",
+			expected: []string{},
+		},
+	}
+	for _, test := range tests {
+		names, err := test.query.Parameters(test.dialect)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", test.name, err)
+		}
+		if !reflect.DeepEqual(names, test.expected) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, names)
+		}
+	}
+
+	if _, err := Query("SELECT ? + :a").Parameters(DialectSqlite); err == nil {
+		t.Fatalf("Expected an error when mixing placeholder styles")
+	}
+}
+
+func TestBind(t *testing.T) {
+	values := map[string]any{":name": "alice", ":age": int64(30)}
+	query := Query("SELECT * FROM users WHERE name = :name AND age > :age OR nick = :name")
+
+	bound, args, err := query.Bind(DialectPostgres, values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if bound != "SELECT * FROM users WHERE name = $1 AND age > $2 OR nick = $1" {
+		t.Fatalf("Wrong postgres query: %s", bound)
+	}
+	if !reflect.DeepEqual(args, []any{"alice", int64(30)}) {
+		t.Fatalf("Wrong postgres args: %v", args)
+	}
+
+	bound, args, err = query.Bind(DialectMysql, values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if bound != "SELECT * FROM users WHERE name = ? AND age > ? OR nick = ?" {
+		t.Fatalf("Wrong mysql query: %s", bound)
+	}
+	if !reflect.DeepEqual(args, []any{"alice", int64(30), "alice"}) {
+		t.Fatalf("Wrong mysql args: %v", args)
+	}
+
+	bound, args, err = Query("SELECT $2, $1").Bind(DialectPostgres, map[string]any{"$1": "a", "$2": nil})
+	if err != nil || bound != "SELECT $2, $1" || !reflect.DeepEqual(args, []any{"a", nil}) {
+		t.Fatalf("Numbered parameters should be kept: %s %v %v", bound, args, err)
+	}
+
+	_, _, err = query.Bind(DialectMysql, map[string]any{":name": "alice"})
+	if err == nil || !strings.Contains(err.Error(), ":age") {
+		t.Fatalf("Expected an error for the missing value, got %v", err)
+	}
+}
+
+func TestParamTypeConvert(t *testing.T) {
+	tests := []struct {
+		paramType ParamType
+		value     string
+		expected  any
+	}{
+		{ParamText, " 42 ", " 42 "},
+		{ParamInteger, "42", int64(42)},
+		{ParamNumber, "4.5", 4.5},
+		{ParamBoolean, "true", true},
+		{ParamNull, "anything", nil},
+	}
+	for _, test := range tests {
+		converted, err := test.paramType.Convert(test.value)
+		if err != nil {
+			t.Fatalf("Unexpected error converting %q to %s: %s", test.value, test.paramType, err)
+		}
+		if converted != test.expected {
+			t.Fatalf("Converting %q to %s gave %#v", test.value, test.paramType, converted)
+		}
+	}
+	if _, err := ParamInteger.Convert("4.5"); err == nil {
+		t.Fatalf("Expected an error for an invalid integer")
+	}
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"errors"
+	"sort"
+)
+
+// PlanNode is a step in the execution plan of a query
+type PlanNode struct {
+	// Operation is the kind of step, e.g. Seq Scan or Nested loop
+	Operation string
+	// Detail describes what the step operates on, e.g. the table and index
+	Detail string
+	// EstimatedRows and Cost are estimates of the planner,
+	// Cost includes the cost of the children
+	EstimatedRows float64
+	Cost          float64
+	HasEstimate   bool
+	// ActualRows and Time are measured when the plan was analyzed,
+	// Time is in milliseconds and includes the time of the children
+	ActualRows float64
+	Time       float64
+	HasActual  bool
+	Children   []*PlanNode
+}
+
+// Plan is the execution plan of a query
+type Plan struct {
+	Root *PlanNode
+	// Analyzed is true when the query was executed to measure the plan
+	Analyzed bool
+}
+
+// ExclusiveCost is the cost or time spent in the node itself, excluding its children
+// Time is used when the plan was analyzed, otherwise the estimated cost
+func (n *PlanNode) ExclusiveCost(analyzed bool) float64 {
+	cost := n.cost(analyzed)
+	for _, child := range n.Children {
+		cost -= child.cost(analyzed)
+	}
+	return max(cost, 0)
+}
+
+func (n *PlanNode) cost(analyzed bool) float64 {
+	if analyzed {
+		return n.Time
+	}
+	return n.Cost
+}
+
+// Walk calls fn for the node and all its descendants, depth first
+func (n *PlanNode) Walk(fn func(node *PlanNode, depth int)) {
+	n.walk(fn, 0)
+}
+
+func (n *PlanNode) walk(fn func(node *PlanNode, depth int), depth int) {
+	fn(n, depth)
+	for _, child := range n.Children {
+		child.walk(fn, depth+1)
+	}
+}
+
+// FirstValue returns the value in the first cell of results,
+// for statements like EXPLAIN that return a single value
+func FirstValue(results []*QueryResult) (string, error) {
+	if len(results) == 0 || len(results[0].Rows) == 0 || len(results[0].Rows[0]) == 0 {
+		return "", errors.New("no value returned")
+	}
+	return results[0].Rows[0][0].Value, nil
+}
+
+// MostExpensive returns at most count nodes with the highest exclusive cost,
+// nodes without any cost are never returned
+func (p *Plan) MostExpensive(count int) []*PlanNode {
+	nodes := []*PlanNode{}
+	p.Root.Walk(func(node *PlanNode, depth int) {
+		if node.ExclusiveCost(p.Analyzed) > 0 {
+			nodes = append(nodes, node)
+		}
+	})
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].ExclusiveCost(p.Analyzed) > nodes[j].ExclusiveCost(p.Analyzed)
+	})
+	if len(nodes) > count {
+		nodes = nodes[:count]
+	}
+	return nodes
+}
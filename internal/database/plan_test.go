@@ -0,0 +1,27 @@
+package database
+
+import (
+	"testing"
+)
+
+func TestMostExpensive(t *testing.T) {
+	scan := &PlanNode{Operation: "Seq Scan", Cost: 30, Time: 1}
+	lookup := &PlanNode{Operation: "Index Scan", Cost: 8, Time: 3}
+	join := &PlanNode{Operation: "Hash Join", Cost: 40, Time: 4.5, Children: []*PlanNode{scan, lookup}}
+	sort := &PlanNode{Operation: "Sort", Cost: 40, Time: 5, Children: []*PlanNode{join}}
+
+	plan := &Plan{Root: sort}
+	expensive := plan.MostExpensive(2)
+	if len(expensive) != 2 || expensive[0] != scan || expensive[1] != lookup {
+		t.Fatalf("Incorrect most expensive estimated nodes %v", expensive)
+	}
+
+	plan.Analyzed = true
+	expensive = plan.MostExpensive(3)
+	if len(expensive) != 3 || expensive[0] != lookup || expensive[1] != scan || expensive[2] != sort {
+		t.Fatalf("Incorrect most expensive analyzed nodes %v", expensive)
+	}
+	if cost := join.ExclusiveCost(true); cost != 0.5 {
+		t.Fatalf("Incorrect exclusive time %f", cost)
+	}
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Progress describes the running query, or the last query when none is running
+type Progress struct {
+	// Running is true until the query returned its first page of rows or its result
+	Running bool
+	Elapsed time.Duration
+	// Rows is the amount of rows fetched so far
+	Rows int64
+}
+
+func (p Progress) String() string {
+	return fmt.Sprintf("%s, %d rows fetched", FormatDuration(p.Elapsed), p.Rows)
+}
+
+// FormatDuration formats d with a precision that suits how long queries take
+func FormatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+// queryProgress tracks a query started with startQuery
+// It is updated while the rows are scanned so it can be read from other goroutines
+type queryProgress struct {
+	started time.Time
+	rows    atomic.Int64
+	// finished is the unix time in nanoseconds the query returned, 0 while it is running
+	finished atomic.Int64
+}
+
+func newQueryProgress() *queryProgress {
+	return &queryProgress{started: time.Now()}
+}
+
+// addRows counts rows as fetched, p can be nil for fetches that are not tracked
+func (p *queryProgress) addRows(rows int) {
+	if p != nil {
+		p.rows.Add(int64(rows))
+	}
+}
+
+// finish stops the clock, only the first call has effect
+func (p *queryProgress) finish() {
+	p.finished.CompareAndSwap(0, time.Now().UnixNano())
+}
+
+func (p *queryProgress) progress() Progress {
+	finished := p.finished.Load()
+	progress := Progress{
+		Running: finished == 0,
+		Elapsed: time.Since(p.started),
+		Rows:    p.rows.Load(),
+	}
+	if !progress.Running {
+		progress.Elapsed = time.Unix(0, finished).Sub(p.started)
+	}
+	return progress
+}
+
+// Progress returns how long the current query has been running and how many rows it fetched
+func (b *BaseDriver) Progress() Progress {
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.progress == nil {
+		return Progress{}
+	}
+	return b.progress.progress()
+}
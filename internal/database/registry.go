@@ -0,0 +1,126 @@
+package database
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Kavantix/lazysql/internal/credentials"
+)
+
+// Field is a connection setting a driver uses
+type Field string
+
+const (
+	FieldHost     Field = "host"
+	FieldPort     Field = "port"
+	FieldUser     Field = "user"
+	FieldPassword Field = "password"
+	// FieldFile is the path of the database for file based databases
+	FieldFile Field = "file"
+)
+
+// DriverInfo describes a kind of database and how to connect to it
+type DriverInfo struct {
+	// Name is how the kind of database is called in the config, e.g. postgresql
+	Name string
+	// Aliases are other accepted names, like the schemes of connection urls
+	Aliases []string
+	// DefaultPort is used when no port is configured, 0 when the driver does not use a port
+	DefaultPort uint16
+	// Fields are the settings the driver uses to connect
+	Fields []Field
+	// Required are the fields that cannot be empty
+	Required []Field
+	// Credentials resolves the settings that are left empty
+	// from the files and environment variables the clients of the database use
+	// When nil empty settings stay empty
+	Credentials func(c credentials.Credentials, env credentials.Environment) (credentials.Credentials, error)
+	// New connects to the database
+	New func(dsn Dsn) (Driver, error)
+}
+
+// Uses reports whether the driver uses field to connect
+func (d DriverInfo) Uses(field Field) bool {
+	return slices.Contains(d.Fields, field)
+}
+
+// CheckRequired returns an error for the first required field that is empty in dsn
+func (d DriverInfo) CheckRequired(dsn Dsn) error {
+	for _, field := range d.Required {
+		empty := false
+		switch field {
+		case FieldHost:
+			empty = dsn.Host == ""
+		case FieldPort:
+			empty = dsn.Port == 0
+		case FieldUser:
+			empty = dsn.User == ""
+		case FieldPassword:
+			empty = dsn.Password == ""
+		case FieldFile:
+			empty = dsn.File == ""
+		}
+		if empty {
+			return fmt.Errorf("%s cannot be empty for %s", field, d.Name)
+		}
+	}
+	return nil
+}
+
+var (
+	driversMutex sync.RWMutex
+	drivers      = map[string]DriverInfo{}
+)
+
+// Register makes a driver available by its name and aliases,
+// it panics when a name is already registered like database/sql does
+func Register(info DriverInfo) {
+	driversMutex.Lock()
+	defer driversMutex.Unlock()
+	if info.New == nil {
+		panic("database: Register driver " + info.Name + " without constructor")
+	}
+	names := append([]string{info.Name}, info.Aliases...)
+	for _, name := range names {
+		if _, exists := drivers[name]; exists {
+			panic("database: Register called twice for driver " + name)
+		}
+	}
+	for _, name := range names {
+		drivers[name] = info
+	}
+}
+
+// LookupDriver returns the driver registered with name as its name or one of its aliases
+func LookupDriver(name string) (DriverInfo, error) {
+	driversMutex.RLock()
+	defer driversMutex.RUnlock()
+	info, ok := drivers[strings.TrimSpace(name)]
+	if !ok {
+		return DriverInfo{}, fmt.Errorf("unknown type %s, should be one of (%s)", name, strings.Join(driverNames(), ", "))
+	}
+	return info, nil
+}
+
+// DriverNames returns the names of all registered drivers, sorted
+func DriverNames() []string {
+	driversMutex.RLock()
+	defer driversMutex.RUnlock()
+	return driverNames()
+}
+
+// driverNames returns the names of all registered drivers without their aliases
+// driversMutex should be held by the caller
+func driverNames() []string {
+	names := []string{}
+	for name, info := range drivers {
+		if name == info.Name {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	Register(DriverInfo{
+		Name:     "testdb",
+		Aliases:  []string{"testdb2"},
+		Fields:   []Field{FieldFile},
+		Required: []Field{FieldFile},
+		New: func(dsn Dsn) (Driver, error) {
+			return nil, errors.New("not implemented")
+		},
+	})
+	info, err := LookupDriver("testdb2")
+	if err != nil {
+		t.Fatalf("Alias was not registered: %s", err)
+	}
+	if info.Name != "testdb" || !info.Uses(FieldFile) || info.Uses(FieldHost) {
+		t.Fatalf("Wrong driver info %#v", info)
+	}
+	if names := strings.Join(DriverNames(), ","); !strings.Contains(names, "testdb") || strings.Contains(names, "testdb2") {
+		t.Fatalf("Names should only contain the name of the driver: %s", names)
+	}
+	if err := info.CheckRequired(Dsn{}); err == nil || err.Error() != "file cannot be empty for testdb" {
+		t.Fatalf("Expected the file to be required, got %v", err)
+	}
+	if err := info.CheckRequired(Dsn{File: "test.db"}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := LookupDriver("unknown"); err == nil {
+		t.Fatalf("Expected an error for an unknown driver")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Registering a name twice should panic")
+		}
+	}()
+	Register(DriverInfo{Name: "other", Aliases: []string{"testdb"}, New: info.New})
+}
@@ -0,0 +1,235 @@
+package database
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Dialect is the flavour of sql a driver speaks
+type Dialect uint8
+
+const (
+	DialectMysql Dialect = iota
+	DialectPostgres
+	DialectSqlite
+)
+
+// Statement is a single statement in a script
+type Statement struct {
+	Query Query
+	// Start and End are the byte offsets of the statement in the script,
+	// excluding surrounding whitespace and the delimiter
+	Start, End int
+}
+
+// SplitStatements splits a script into its statements
+// Delimiters inside quotes, comments and postgres dollar quoted strings are ignored
+// For mysql the client side DELIMITER command is supported,
+// for sqlite the body of a CREATE TRIGGER is kept together
+func SplitStatements(script string, dialect Dialect) []Statement {
+	statements := []Statement{}
+	delimiter := ";"
+	start := 0
+	lineStart := true
+	inTrigger := false
+	// cases is how many CASE expressions in the body of a trigger are not ended yet,
+	// their END does not end the body
+	cases := 0
+	lastWord := ""
+
+	addStatement := func(end int) {
+		text := script[start:end]
+		trimmedStart := start + len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+		trimmedEnd := start + len(strings.TrimRightFunc(text, unicode.IsSpace))
+		if trimmedStart < trimmedEnd {
+			query := Query(script[trimmedStart:trimmedEnd])
+			if query.Keyword() != "" {
+				statements = append(statements, Statement{
+					Query: query,
+					Start: trimmedStart,
+					End:   trimmedEnd,
+				})
+			}
+		}
+		inTrigger = false
+		cases = 0
+		lastWord = ""
+	}
+
+	for i := 0; i < len(script); {
+		c := script[i]
+		if lineStart && dialect == DialectMysql {
+			if newDelimiter, end, ok := parseDelimiterCommand(script, i); ok {
+				addStatement(i)
+				delimiter = newDelimiter
+				start = end
+				i = end
+				continue
+			}
+		}
+		lineStart = c == '\n'
+
+		switch {
+		case strings.HasPrefix(script[i:], delimiter) && (!inTrigger || lastWord == "END"):
+			addStatement(i)
+			i += len(delimiter)
+			start = i
+			continue
+		case c == '\'' || c == '"' || (c == '`' && dialect != DialectPostgres):
+			i = skipQuoted(script, i, dialect == DialectMysql)
+			lastWord = ""
+			continue
+		case c == '-' && strings.HasPrefix(script[i:], "--"),
+			c == '#' && dialect == DialectMysql:
+			i = skipLine(script, i)
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			i = skipBlockComment(script, i, dialect == DialectPostgres)
+			continue
+		case c == '$' && dialect == DialectPostgres:
+			if end, ok := skipDollarQuoted(script, i); ok {
+				i = end
+				lastWord = ""
+				continue
+			}
+		case isWordByte(c):
+			end := i
+			for end < len(script) && isWordByte(script[end]) {
+				end++
+			}
+			lastWord = strings.ToUpper(script[i:end])
+			if dialect == DialectSqlite && lastWord == "TRIGGER" && Query(script[start:i]).Keyword() == "CREATE" {
+				inTrigger = true
+			}
+			if inTrigger && lastWord == "CASE" {
+				cases++
+			}
+			if inTrigger && lastWord == "END" && cases > 0 {
+				cases--
+				lastWord = "END CASE"
+			}
+			i = end
+			continue
+		case !unicode.IsSpace(rune(c)):
+			lastWord = ""
+		}
+		i++
+	}
+	addStatement(len(script))
+	return statements
+}
+
+// StatementAt returns the statement the cursor is in
+// When the cursor is between statements the statement before it is returned
+func StatementAt(statements []Statement, cursor int) (Statement, bool) {
+	if len(statements) == 0 {
+		return Statement{}, false
+	}
+	result := statements[0]
+	for _, statement := range statements {
+		if statement.Start > cursor {
+			break
+		}
+		result = statement
+	}
+	return result, true
+}
+
+// parseDelimiterCommand parses a mysql client DELIMITER command at the start of a line
+func parseDelimiterCommand(script string, i int) (delimiter string, end int, ok bool) {
+	lineEnd := strings.IndexByte(script[i:], '\n')
+	if lineEnd < 0 {
+		lineEnd = len(script)
+	} else {
+		lineEnd += i
+	}
+	fields := strings.Fields(script[i:lineEnd])
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "DELIMITER") {
+		return "", 0, false
+	}
+	return fields[1], lineEnd, true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+// skipQuoted returns the index after the closing quote of the quoted string at i
+// A doubled quote is an escaped quote, when backslashEscapes is true so is a quote after a backslash
+func skipQuoted(script string, i int, backslashEscapes bool) int {
+	quote := script[i]
+	i++
+	for i < len(script) {
+		switch script[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+			} else {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(script)
+}
+
+func skipLine(script string, i int) int {
+	end := strings.IndexByte(script[i:], '\n')
+	if end < 0 {
+		return len(script)
+	}
+	return i + end
+}
+
+// skipBlockComment returns the index after the end of the comment at i
+// Postgres allows nesting block comments
+func skipBlockComment(script string, i int, nested bool) int {
+	depth := 0
+	for i < len(script) {
+		switch {
+		case strings.HasPrefix(script[i:], "/*"):
+			if depth == 0 || nested {
+				depth++
+			}
+			i += 2
+		case strings.HasPrefix(script[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(script)
+}
+
+// skipDollarQuoted returns the index after a postgres dollar quoted string like $body$...$body$
+// ok is false when the dollar at i does not start one, for example for parameters like $1
+func skipDollarQuoted(script string, i int) (end int, ok bool) {
+	if i > 0 && isWordByte(script[i-1]) {
+		return 0, false
+	}
+	tagEnd := i + 1
+	for tagEnd < len(script) && script[tagEnd] != '$' {
+		c := script[tagEnd]
+		if !isWordByte(c) || (tagEnd == i+1 && c >= '0' && c <= '9') {
+			return 0, false
+		}
+		tagEnd++
+	}
+	if tagEnd >= len(script) {
+		return 0, false
+	}
+	tag := script[i : tagEnd+1]
+	closing := strings.Index(script[tagEnd+1:], tag)
+	if closing < 0 {
+		return len(script), true
+	}
+	return tagEnd + 1 + closing + len(tag), true
+}
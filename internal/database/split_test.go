@@ -0,0 +1,137 @@
+package database
+
+import (
+	"testing"
+)
+
+func statementQueries(statements []Statement) []Query {
+	queries := make([]Query, len(statements))
+	for i, statement := range statements {
+		queries[i] = statement.Query
+	}
+	return queries
+}
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		script   string
+		expected []Query
+	}{
+		{
+			name:     "single without delimiter",
+			dialect:  DialectPostgres,
+			script:   "SELECT 1",
+			expected: []Query{"SELECT 1"},
+		},
+		{
+			name:     "quotes and comments",
+			dialect:  DialectPostgres,
+			script:   "SELECT ';' AS \"a;b\"; -- comment; here\nSELECT 2 /* ; */;\n\n",
+			expected: []Query{"SELECT ';' AS \"a;b\"", "-- comment; here\nSELECT 2 /* ; */"},
+		},
+		{
+			name:     "array slices",
+			dialect:  DialectPostgres,
+			script:   "SELECT arr[1:n] FROM t WHERE id = :id;\nSELECT arr[lo:hi] FROM t",
+			expected: []Query{"SELECT arr[1:n] FROM t WHERE id = :id", "SELECT arr[lo:hi] FROM t"},
+		},
+		{
+			name:     "comment only statements are skipped",
+			dialect:  DialectPostgres,
+			script:   "SELECT 1;\n-- trailing comment",
+			expected: []Query{"SELECT 1"},
+		},
+		{
+			name:    "dollar quoting",
+			dialect: DialectPostgres,
+			script: "CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql;\n" +
+				"SELECT $$a;b$$, $1;",
+			expected: []Query{
+				"CREATE FUNCTION f() RETURNS int AS $body$ BEGIN RETURN 1; END; $body$ LANGUAGE plpgsql",
+				"SELECT $$a;b$$, $1",
+			},
+		},
+		{
+			name:     "mysql backslash escapes and hash comments",
+			dialect:  DialectMysql,
+			script:   "SELECT 'it\\'s;'; # comment;\nSELECT `a;b`",
+			expected: []Query{"SELECT 'it\\'s;'", "# comment;\nSELECT `a;b`"},
+		},
+		{
+			name:    "mysql delimiter",
+			dialect: DialectMysql,
+			script: "DELIMITER $$\n" +
+				"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END$$\n" +
+				"DELIMITER ;\n" +
+				"CALL p();",
+			expected: []Query{
+				"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END",
+				"CALL p()",
+			},
+		},
+		{
+			name:    "sqlite trigger",
+			dialect: DialectSqlite,
+			script: "CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE b SET c = 1; DELETE FROM d; END;\n" +
+				"SELECT 1;",
+			expected: []Query{
+				"CREATE TRIGGER t AFTER INSERT ON a BEGIN UPDATE b SET c = 1; DELETE FROM d; END",
+				"SELECT 1",
+			},
+		},
+		{
+			name:    "sqlite trigger with case",
+			dialect: DialectSqlite,
+			script: "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n" +
+				"  UPDATE b SET c = CASE WHEN new.x > 0 THEN 1 ELSE CASE new.y WHEN 1 THEN 2 END END;\n" +
+				"  INSERT INTO d VALUES (CASE new.x WHEN 1 THEN 'one' END);\n" +
+				"END;\n" +
+				"SELECT CASE WHEN 1 THEN 2 END;",
+			expected: []Query{
+				"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n" +
+					"  UPDATE b SET c = CASE WHEN new.x > 0 THEN 1 ELSE CASE new.y WHEN 1 THEN 2 END END;\n" +
+					"  INSERT INTO d VALUES (CASE new.x WHEN 1 THEN 'one' END);\n" +
+					"END",
+				"SELECT CASE WHEN 1 THEN 2 END",
+			},
+		},
+	}
+	for _, test := range tests {
+		statements := SplitStatements(test.script, test.dialect)
+		queries := statementQueries(statements)
+		if len(queries) != len(test.expected) {
+			t.Fatalf("%s: expected %d statements, got %d: %q", test.name, len(test.expected), len(queries), queries)
+		}
+		for i := range queries {
+			if queries[i] != test.expected[i] {
+				t.Fatalf("%s: statement %d is `%s` instead of `%s`", test.name, i, queries[i], test.expected[i])
+			}
+			statement := statements[i]
+			if Query(test.script[statement.Start:statement.End]) != statement.Query {
+				t.Fatalf("%s: offsets of statement %d do not match its query", test.name, i)
+			}
+		}
+	}
+}
+
+func TestStatementAt(t *testing.T) {
+	script := "SELECT 1;\nSELECT 2;\n"
+	statements := SplitStatements(script, DialectPostgres)
+	for cursor, expected := range map[int]Query{
+		0:  "SELECT 1",
+		8:  "SELECT 1",
+		9:  "SELECT 1",
+		10: "SELECT 2",
+		20: "SELECT 2",
+	} {
+		statement, ok := StatementAt(statements, cursor)
+		if !ok || statement.Query != expected {
+			t.Fatalf("Statement at %d is `%s` instead of `%s`", cursor, statement.Query, expected)
+		}
+	}
+	if _, ok := StatementAt(nil, 0); ok {
+		t.Fatalf("No statement expected in empty script")
+	}
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ExecResult describes the outcome of a statement that does not return rows
+type ExecResult struct {
+	RowsAffected int64
+	// LastInsertId is only meaningful when HasLastInsertId is true
+	LastInsertId    int64
+	HasLastInsertId bool
+	// CommandTag is the status the server reported for the statement, e.g. `UPDATE 3`
+	// Empty when the driver does not support command tags
+	CommandTag string
+	// Duration is how long the statement took to execute
+	Duration time.Duration
+}
+
+func (r *ExecResult) String() string {
+	message := r.CommandTag
+	if message == "" {
+		if r.RowsAffected == 1 {
+			message = "1 row affected"
+		} else {
+			message = fmt.Sprintf("%d rows affected", r.RowsAffected)
+		}
+	}
+	if r.HasLastInsertId {
+		message += fmt.Sprintf(", last insert id %d", r.LastInsertId)
+	}
+	return message
+}
+
+// Keyword returns the first keyword of the query in upper case
+// Leading whitespace, comments and parentheses are skipped
+func (q Query) Keyword() string {
+	query := string(q)
+	for {
+		query = strings.TrimLeftFunc(query, func(r rune) bool {
+			return unicode.IsSpace(r) || r == '('
+		})
+		switch {
+		case strings.HasPrefix(query, "--"), strings.HasPrefix(query, "#"):
+			end := strings.IndexByte(query, '\n')
+			if end < 0 {
+				return ""
+			}
+			query = query[end+1:]
+		case strings.HasPrefix(query, "/*"):
+			end := strings.Index(query, "*/")
+			if end < 0 {
+				return ""
+			}
+			query = query[end+2:]
+		default:
+			end := strings.IndexFunc(query, func(r rune) bool {
+				return !unicode.IsLetter(r) && r != '_'
+			})
+			if end < 0 {
+				end = len(query)
+			}
+			return strings.ToUpper(query[:end])
+		}
+	}
+}
+
+// ReturnsRows reports whether the query is expected to return rows
+// and should therefore be executed with Driver.Query instead of Driver.Exec
+func (q Query) ReturnsRows() bool {
+	switch q.Keyword() {
+	case "SELECT", "WITH", "SHOW", "EXPLAIN", "DESCRIBE", "DESC", "VALUES", "TABLE", "PRAGMA", "CALL", "FETCH":
+		return true
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+		return containsWord(string(q), "RETURNING")
+	default:
+		return false
+	}
+}
+
+// IsWrite reports whether the query changes data or schema
+func (q Query) IsWrite() bool {
+	switch q.Keyword() {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT",
+		"CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT",
+		"GRANT", "REVOKE", "COPY", "LOAD", "VACUUM", "REINDEX", "CLUSTER", "REFRESH":
+		return true
+	default:
+		return false
+	}
+}
+
+func containsWord(text, word string) bool {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '_'
+	})
+	for _, field := range fields {
+		if strings.EqualFold(field, word) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package database
+
+// TableStructure describes the columns, keys and indexes of a table
+type TableStructure struct {
+	Columns []ColumnDefinition
+	// PrimaryKey contains the columns of the primary key in order, empty when the table has none
+	PrimaryKey  []string
+	Indexes     []Index
+	ForeignKeys []ForeignKey
+}
+
+// ColumnDefinition is a column as it is defined in a table
+type ColumnDefinition struct {
+	Name string
+	// Type is the type as it was declared, e.g. varchar(255)
+	Type     string
+	Nullable bool
+	// Default is the default expression, only meaningful when HasDefault is true
+	Default    string
+	HasDefault bool
+	Comment    string
+}
+
+type Index struct {
+	Name string
+	// Columns contains the indexed columns in order, expressions for expression indexes
+	Columns []string
+	Unique  bool
+	Primary bool
+}
+
+type ForeignKey struct {
+	Name              string
+	Columns           []string
+	ReferencedTable   string
+	ReferencedColumns []string
+	// OnUpdate and OnDelete are the referential actions, e.g. CASCADE
+	OnUpdate, OnDelete string
+}
+
+// IsPrimaryKey reports whether column is part of the primary key
+func (s *TableStructure) IsPrimaryKey(column string) bool {
+	for _, name := range s.PrimaryKey {
+		if name == column {
+			return true
+		}
+	}
+	return false
+}
+
+// AddIndexColumn appends column to the index named name,
+// the index is added first when it is not the last index
+// Drivers use this to build the indexes from rows ordered by index
+func (s *TableStructure) AddIndexColumn(name string, unique, primary bool, column string) {
+	if len(s.Indexes) == 0 || s.Indexes[len(s.Indexes)-1].Name != name {
+		s.Indexes = append(s.Indexes, Index{Name: name, Unique: unique, Primary: primary})
+	}
+	index := &s.Indexes[len(s.Indexes)-1]
+	index.Columns = append(index.Columns, column)
+	if primary {
+		s.PrimaryKey = append(s.PrimaryKey, column)
+	}
+}
+
+// AddForeignKeyColumn appends a column pair to the foreign key named key.Name,
+// the foreign key is added first when it is not the last foreign key
+func (s *TableStructure) AddForeignKeyColumn(key ForeignKey, column, referencedColumn string) {
+	if len(s.ForeignKeys) == 0 || s.ForeignKeys[len(s.ForeignKeys)-1].Name != key.Name {
+		s.ForeignKeys = append(s.ForeignKeys, key)
+	}
+	foreignKey := &s.ForeignKeys[len(s.ForeignKeys)-1]
+	foreignKey.Columns = append(foreignKey.Columns, column)
+	foreignKey.ReferencedColumns = append(foreignKey.ReferencedColumns, referencedColumn)
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// TimeoutError is returned when a query ran longer than the query timeout
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("query timed out after %s", e.Timeout)
+}
+
+// queryTimeout cancels a query when it runs longer than timeout
+// A timer is used instead of a context deadline
+// so the rows of a result can still be fetched after the timeout
+type queryTimeout struct {
+	timeout   time.Duration
+	timer     *time.Timer
+	expired   atomic.Bool
+	isTimeout func(err error) bool
+}
+
+// newQueryTimeout starts the timeout of a query that is cancelled by cancel
+// queryMutex should be held by the caller
+func (b *BaseDriver) newQueryTimeout(cancel context.CancelFunc) *queryTimeout {
+	t := &queryTimeout{
+		timeout:   b.QueryTimeout,
+		isTimeout: b.IsTimeout,
+	}
+	if b.skipTimeout {
+		t.timeout = 0
+		b.skipTimeout = false
+	}
+	// Cancelling a statement on the client closes the connection of an open transaction,
+	// so in a transaction only the server ends statements when it can
+	if t.timeout > 0 && (b.transaction == nil || b.ServerTimeout == nil) {
+		t.timer = time.AfterFunc(t.timeout, func() {
+			t.expired.Store(true)
+			cancel()
+		})
+	}
+	return t
+}
+
+// stop stops the timer, the query is no longer cancelled after it
+func (t *queryTimeout) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// err replaces err with a TimeoutError when the query was cancelled because it ran too long
+// or the server reported that it timed out
+func (t *queryTimeout) err(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t.expired.Load() || (t.isTimeout != nil && t.isTimeout(err)) {
+		return &TimeoutError{Timeout: t.timeout}
+	}
+	return err
+}
+
+// setServerTimeout makes the server end a statement on conn that runs longer than the timeout,
+// nothing is set when no query timeout is configured so the server default is kept
+func (b *BaseDriver) setServerTimeout(ctx context.Context, conn *sql.Conn, t *queryTimeout) error {
+	if b.QueryTimeout == 0 || b.ServerTimeout == nil {
+		return nil
+	}
+	// A previous query could have set a timeout on the same connection, so it is also set when skipped
+	return b.ServerTimeout(ctx, conn, t.timeout)
+}
+
+// WithoutTimeout makes the next query run without the query timeout,
+// for queries that are deliberately slow
+func (b *BaseDriver) WithoutTimeout() {
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	b.skipTimeout = true
+}
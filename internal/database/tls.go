@@ -0,0 +1,139 @@
+package database
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSMode determines whether connections are encrypted and how the server is verified,
+// the modes are named after the sslmode of libpq
+type TLSMode string
+
+const (
+	// TLSDefault leaves the mode to the driver, which is TLSPrefer for all drivers
+	TLSDefault TLSMode = ""
+	TLSDisable TLSMode = "disable"
+	// TLSPrefer encrypts the connection when the server supports it, without verifying the server
+	TLSPrefer TLSMode = "prefer"
+	// TLSRequire encrypts the connection without verifying the server
+	TLSRequire TLSMode = "require"
+	// TLSVerifyCA verifies the certificate of the server is signed by a trusted authority
+	TLSVerifyCA TLSMode = "verify-ca"
+	// TLSVerifyFull also verifies the certificate is for the server name
+	TLSVerifyFull TLSMode = "verify-full"
+)
+
+var TLSModes = []TLSMode{TLSDisable, TLSPrefer, TLSRequire, TLSVerifyCA, TLSVerifyFull}
+
+// ParseTLSMode returns the mode named mode, an empty name is TLSDefault
+func ParseTLSMode(mode string) (TLSMode, error) {
+	if mode == "" {
+		return TLSDefault, nil
+	}
+	for _, known := range TLSModes {
+		if string(known) == mode {
+			return known, nil
+		}
+	}
+	names := make([]string, len(TLSModes))
+	for i, known := range TLSModes {
+		names[i] = string(known)
+	}
+	return TLSDefault, fmt.Errorf("unknown TLS mode %s, should be one of (%s)", mode, strings.Join(names, ", "))
+}
+
+// Verifies reports whether the mode verifies the certificate of the server
+func (m TLSMode) Verifies() bool {
+	return m == TLSVerifyCA || m == TLSVerifyFull
+}
+
+// TLS configures how connections to the server are encrypted
+type TLS struct {
+	Mode TLSMode
+	// CAFile is a PEM bundle of the authorities that are trusted to sign the certificate of the server,
+	// the roots of the system are trusted when empty
+	CAFile string
+	// CertFile and KeyFile are the certificate the client authenticates with, both are empty when not used
+	CertFile, KeyFile string
+	// ServerName is the name the certificate is verified against, defaults to the host
+	ServerName string
+}
+
+// HasFiles reports whether any certificate files are configured
+func (t TLS) HasFiles() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != ""
+}
+
+// Config returns the tls config to connect to host with
+// Returns nil when the mode is TLSDisable
+func (t TLS) Config(host string) (*tls.Config, error) {
+	if t.Mode == TLSDisable {
+		return nil, nil
+	}
+	config := &tls.Config{
+		ServerName: t.ServerName,
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		return nil, errors.New("both a client certificate and key are needed")
+	}
+	if t.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", t.CAFile)
+		}
+	}
+	switch t.Mode {
+	case TLSVerifyFull:
+	case TLSVerifyCA:
+		// The chain is verified without checking the name, which crypto/tls cannot do by itself
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	default:
+		config.InsecureSkipVerify = true
+	}
+	return config, nil
+}
+
+// verifyChain verifies the certificates of the server are signed by roots
+func verifyChain(roots *x509.CertPool) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server did not send a certificate")
+		}
+		options := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		certificates := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			certificate, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certificates[i] = certificate
+			if i > 0 {
+				options.Intermediates.AddCert(certificate)
+			}
+		}
+		_, err := certificates[0].Verify(options)
+		return err
+	}
+}
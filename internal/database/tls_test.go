@@ -0,0 +1,155 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	der         []byte
+}
+
+func createCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{name}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		signer, signerKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %s", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("Failed to parse certificate: %s", err)
+	}
+	return &testCertificate{certificate: certificate, key: key, der: der}
+}
+
+func writeCAFile(t *testing.T, ca *testCertificate) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.der})
+	if err := os.WriteFile(path, bundle, 0600); err != nil {
+		t.Fatalf("Failed to write CA bundle: %s", err)
+	}
+	return path
+}
+
+// startTLSServer starts a server that completes handshakes using the server certificate
+func startTLSServer(t *testing.T, server *testCertificate) string {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{
+			Certificate: [][]byte{server.der},
+			PrivateKey:  server.key,
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func handshake(address string, config *tls.Config) error {
+	conn, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return tls.Client(conn, config).Handshake()
+}
+
+func TestTLSConfig(t *testing.T) {
+	ca := createCertificate(t, "Private CA", nil)
+	otherCa := createCertificate(t, "Other CA", nil)
+	address := startTLSServer(t, createCertificate(t, "db.internal", ca))
+	caFile, otherCaFile := writeCAFile(t, ca), writeCAFile(t, otherCa)
+
+	tests := []struct {
+		name    string
+		tls     TLS
+		succeed bool
+	}{
+		{"verify-full", TLS{Mode: TLSVerifyFull, CAFile: caFile, ServerName: "db.internal"}, true},
+		{"verify-full wrong name", TLS{Mode: TLSVerifyFull, CAFile: caFile, ServerName: "other.internal"}, false},
+		{"verify-full other CA", TLS{Mode: TLSVerifyFull, CAFile: otherCaFile, ServerName: "db.internal"}, false},
+		{"verify-ca wrong name", TLS{Mode: TLSVerifyCA, CAFile: caFile, ServerName: "other.internal"}, true},
+		{"verify-ca other CA", TLS{Mode: TLSVerifyCA, CAFile: otherCaFile}, false},
+		{"require other CA", TLS{Mode: TLSRequire, CAFile: otherCaFile}, true},
+		{"prefer", TLS{Mode: TLSPrefer}, true},
+	}
+	for _, test := range tests {
+		config, err := test.tls.Config("127.0.0.1")
+		if err != nil {
+			t.Fatalf("%s: failed to create config: %s", test.name, err)
+		}
+		err = handshake(address, config)
+		if test.succeed && err != nil {
+			t.Fatalf("%s: handshake failed: %s", test.name, err)
+		}
+		if !test.succeed && err == nil {
+			t.Fatalf("%s: handshake should have failed", test.name)
+		}
+	}
+
+	if config, err := (TLS{Mode: TLSDisable}).Config("localhost"); config != nil || err != nil {
+		t.Fatalf("Disabled TLS should not have a config")
+	}
+	if _, err := (TLS{Mode: TLSRequire, CertFile: caFile}).Config("localhost"); err == nil {
+		t.Fatalf("Expected an error for a certificate without key")
+	}
+}
+
+func TestParseTLSMode(t *testing.T) {
+	for _, mode := range TLSModes {
+		if parsed, err := ParseTLSMode(string(mode)); err != nil || parsed != mode {
+			t.Fatalf("Failed to parse %s", mode)
+		}
+	}
+	if mode, err := ParseTLSMode(""); err != nil || mode != TLSDefault {
+		t.Fatalf("Empty mode should be the default")
+	}
+	if _, err := ParseTLSMode("verify"); err == nil {
+		t.Fatalf("Expected an error for an unknown mode")
+	}
+}
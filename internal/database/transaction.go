@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrTransactionAborted is returned when the connection of the open transaction was closed by a statement,
+// the server rolls back the transaction of a closed connection
+var ErrTransactionAborted = errors.New("transaction aborted, its connection was closed and the server rolled it back")
+
+// TransactionControl is the kind of transaction statement a query is
+type TransactionControl uint8
+
+const (
+	TxNone TransactionControl = iota
+	TxBegin
+	TxCommit
+	TxRollback
+)
+
+// TransactionControl reports whether the query starts or ends a transaction
+// Statements like ROLLBACK TO SAVEPOINT that do not end the transaction return TxNone
+func (q Query) TransactionControl() TransactionControl {
+	statement := strings.Join(strings.Fields(strings.ToUpper(strings.TrimRight(string(q), "; \t\n"))), " ")
+	switch statement {
+	case "BEGIN", "BEGIN WORK", "BEGIN TRANSACTION", "START TRANSACTION":
+		return TxBegin
+	case "COMMIT", "COMMIT WORK", "COMMIT TRANSACTION", "END", "END TRANSACTION":
+		return TxCommit
+	case "ROLLBACK", "ROLLBACK WORK", "ROLLBACK TRANSACTION", "ABORT":
+		return TxRollback
+	default:
+		return TxNone
+	}
+}
+
+// conn returns the connection a statement should be executed on
+// When a transaction is open its connection is returned, otherwise one from the pool
+// release must be called when the connection is no longer needed
+func (b *BaseDriver) conn(ctx context.Context) (conn *sql.Conn, release func(), inTransaction bool, err error) {
+	b.queryMutex.Lock()
+	if b.transaction != nil {
+		b.txStatements += 1
+		conn = b.transaction
+		b.queryMutex.Unlock()
+		return conn, func() {}, true, nil
+	}
+	b.queryMutex.Unlock()
+	conn, err = b.Db.Conn(ctx)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return conn, func() { conn.Close() }, false, nil
+}
+
+// Begin starts a transaction on a dedicated connection,
+// all queries are executed in it until Commit or Rollback is called
+func (b *BaseDriver) Begin() error {
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.transaction != nil {
+		return errors.New("a transaction is already open")
+	}
+	b.closeOpenResult()
+	conn, err := b.Db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(context.Background(), "BEGIN"); err != nil {
+		conn.Close()
+		return err
+	}
+	b.transaction = conn
+	b.txStatements = 0
+	return nil
+}
+
+func (b *BaseDriver) Commit() error {
+	return b.endTransaction("COMMIT")
+}
+
+func (b *BaseDriver) Rollback() error {
+	return b.endTransaction("ROLLBACK")
+}
+
+func (b *BaseDriver) endTransaction(statement string) error {
+	b.CancelQuery()
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	if b.transaction == nil {
+		return errors.New("no transaction is open")
+	}
+	b.closeOpenResult()
+	conn := b.transaction
+	b.transaction = nil
+	b.txStatements = 0
+	_, err := conn.ExecContext(context.Background(), statement)
+	if err != nil {
+		// The state of the connection is unknown so it should not be reused
+		conn.Raw(func(any) error { return driver.ErrBadConn })
+	}
+	conn.Close()
+	return err
+}
+
+// Transaction reports whether a transaction is open
+// and how many statements have been executed in it
+func (b *BaseDriver) Transaction() (open bool, statements int) {
+	b.queryMutex.Lock()
+	defer b.queryMutex.Unlock()
+	return b.transaction != nil, b.txStatements
+}
+
+// interruptQuery stops the running query before another one is started
+// Cancelling the context of a statement makes drivers like pgx and mysql close its connection,
+// so in a transaction the server is asked to cancel the statement instead when it can
+// queryMutex should be held by the caller
+func (b *BaseDriver) interruptQuery() {
+	if b.cancelFunc == nil {
+		return
+	}
+	if b.transaction != nil && b.CancelOnServer != nil && b.connectionID != 0 {
+		go b.cancelOnServer(b.connectionID)
+		return
+	}
+	b.cancelFunc()
+}
+
+// checkTransaction closes the transaction when err left its connection closed,
+// which happens when the statement on conn was cancelled by the client
+// err is returned wrapped in ErrTransactionAborted in that case
+func (b *BaseDriver) checkTransaction(conn *sql.Conn, err error) error {
+	if err == nil {
+		return nil
+	}
+	b.queryMutex.Lock()
+	inTransaction := conn != nil && b.transaction == conn
+	b.queryMutex.Unlock()
+	if !inTransaction {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), serverCancelTimeout)
+	defer cancel()
+	if conn.PingContext(ctx) == nil {
+		return err
+	}
+	b.queryMutex.Lock()
+	if b.transaction == conn {
+		b.transaction = nil
+		b.txStatements = 0
+	}
+	b.queryMutex.Unlock()
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+	conn.Close()
+	return fmt.Errorf("%w: %w", ErrTransactionAborted, err)
+}
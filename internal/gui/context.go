@@ -20,15 +20,46 @@ type Context interface {
 	ShowError(message string)
 	ShowSuccess(message string)
 	ShowPopup(title, message string, color gocui.Attribute)
+	// ShowChoices shows a popup that closes when one of the choices is chosen using its key
+	ShowChoices(title, message string, choices []Choice)
+	// Confirm asks the user to confirm before onConfirm is called
+	Confirm(message string, onConfirm func() error)
+	// Prompt asks the user to type a value, onSubmit is called with it when enter is pressed
+	// When masked the typed value is not shown, e.g. for passwords
+	Prompt(title, message string, masked bool, onSubmit func(value string))
 	LastLogLine() string
 	Logs() []LogEntry
 }
 
+// Choice is an option in a popup shown with ShowChoices
+type Choice struct {
+	Key   rune
+	Label string
+	// OnChoose is called after the popup is hidden,
+	// the returned error is handled like the error of a keybinding
+	OnChoose func() error
+}
+
+// StatusContext is implemented by contexts that have a status
+// to show on the right side of the footer
+type StatusContext interface {
+	// Status returns the text to show in a warning color, empty when there is nothing to show
+	Status() string
+}
+
 type LogEntry struct {
 	Line string
 	At   time.Time
 }
 
+// Selection is the database and table to select once connected,
+// the zero value selects nothing
+type Selection struct {
+	Database database.Database
+	// Table is the name of the table, optionally prefixed by its schema like schema.table
+	Table string
+}
+
 type DatabaseContext interface {
 	Context
 
@@ -36,9 +67,28 @@ type DatabaseContext interface {
 	// Returns an error if the query failed or was cancelled
 	ExecuteQuery(query database.Query)
 
-	// CancelQuery cancels any running query
-	// Returns true if a query was cancelled
-	CancelQuery() bool
+	// ExecuteQueryWithoutTimeout executes a query without the query timeout of the host,
+	// for queries that are deliberately slow
+	ExecuteQueryWithoutTimeout(query database.Query)
+
+	// ExecuteScript executes statements one after another
+	// Execution stops at the first statement that fails
+	ExecuteScript(statements []database.Statement)
+
+	// ExplainQuery shows the execution plan of query
+	// When analyze is true the query is executed to measure the plan
+	ExplainQuery(query database.Query, analyze bool)
+
+	// Dialect returns the flavour of sql the database speaks
+	Dialect() database.Dialect
+
+	// CancelQuery cancels any running query, on the server too when the driver supports it
+	// Whether the server confirmed the cancel is logged
+	CancelQuery()
+
+	// Transaction reports whether a transaction is open
+	// and how many statements have been executed in it
+	Transaction() (open bool, statements int)
 
 	SelectTablesPane()
 }
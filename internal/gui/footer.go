@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/Kavantix/gocui"
+	"github.com/mattn/go-runewidth"
 )
 
 var (
@@ -66,6 +67,12 @@ func LayoutFooter(g *gocui.Gui, context Context) (int, error) {
 			}
 			footerView.SetOrigin(0, scrollOffset)
 		}
+	} else if statusContext, ok := context.(StatusContext); ok && statusContext.Status() != "" {
+		status := statusContext.Status()
+		statusWidth := runewidth.StringWidth(status)
+		line := runewidth.Truncate(context.LastLogLine(), maxX-statusWidth-2, "…")
+		footerView.WriteString(runewidth.FillRight(line, maxX-statusWidth-1))
+		footerView.WriteString(yellow(status))
 	} else {
 		footerView.WriteString(context.LastLogLine())
 	}
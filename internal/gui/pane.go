@@ -124,27 +124,7 @@ func NewPane[T Paneable](g *gocui.Gui, name string) *Pane[T] {
 		{ch: 'g', fn: p.toTop},
 	}
 	for _, key := range keybindings {
-		var k any = key.key
-		if key.key == 0 {
-			k = key.ch
-		}
-		if err := g.SetKeybinding(name, k, key.mod, func(g *gocui.Gui, v *gocui.View) error {
-			if view.Editable {
-				view.Editor.Edit(v, key.key, key.ch, key.mod)
-			} else {
-				key.fn()
-			}
-			p._lastKey = struct {
-				ch    rune
-				key   gocui.Key
-				setAt time.Time
-			}{
-				ch:    key.ch,
-				key:   key.key,
-				setAt: time.Now(),
-			}
-			return nil
-		}); err != nil {
+		if err := p.setKeybinding(key); err != nil {
 			log.Panicln(err)
 		}
 	}
@@ -152,6 +132,36 @@ func NewPane[T Paneable](g *gocui.Gui, name string) *Pane[T] {
 	return p
 }
 
+// SetKeybinding calls fn when ch is pressed while the pane is focused
+// While filtering ch is typed into the filter instead
+func (p *Pane[T]) SetKeybinding(ch rune, fn func()) error {
+	return p.setKeybinding(keybinding{ch: ch, fn: fn})
+}
+
+func (p *Pane[T]) setKeybinding(key keybinding) error {
+	var k any = key.key
+	if key.key == 0 {
+		k = key.ch
+	}
+	return p.g.SetKeybinding(p.Name, k, key.mod, func(g *gocui.Gui, v *gocui.View) error {
+		if p.View.Editable {
+			p.View.Editor.Edit(v, key.key, key.ch, key.mod)
+		} else {
+			key.fn()
+		}
+		p._lastKey = struct {
+			ch    rune
+			key   gocui.Key
+			setAt time.Time
+		}{
+			ch:    key.ch,
+			key:   key.key,
+			setAt: time.Now(),
+		}
+		return nil
+	})
+}
+
 func (p *Pane[T]) lastKey() struct {
 	ch  rune
 	key gocui.Key
@@ -301,6 +311,14 @@ func (p *Pane[T]) SetContent(content []T) {
 	p.SetCursor(p.cursor)
 }
 
+// ItemUnderCursor returns the item under the cursor, ok is false when the pane is empty
+func (p *Pane[T]) ItemUnderCursor() (item T, ok bool) {
+	if len(p.filteredContent) == 0 {
+		return item, false
+	}
+	return p.filteredContent[p.cursor], true
+}
+
 func (p *Pane[T]) selectUnderCursor() {
 	if p.onSelectItem == nil || len(p.filteredContent) == 0 {
 		return
@@ -332,6 +350,11 @@ func grey(text string) string {
 	return fmt.Sprintf("\x1b[38;5;251m%s\x1b[0m", text)
 }
 
+func yellow(text string) string {
+	// choose color mode ; 256 color mode ; bright yellow ; bold
+	return fmt.Sprintf("\x1b[38;5;11;1m%s\x1b[0m", text)
+}
+
 func darkBlue(text string) string {
 	// choose color mode ; 256 color mode ; dark blue ; bold
 	return fmt.Sprintf("\x1b[38;5;38m%s\x1b[0m", text)
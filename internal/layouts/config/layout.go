@@ -5,50 +5,30 @@ import (
 	"log"
 
 	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/credentials"
 	"github.com/Kavantix/lazysql/internal/database"
-	"github.com/Kavantix/lazysql/internal/database/drivers/mysqldriver"
-	"github.com/Kavantix/lazysql/internal/database/drivers/pgxdriver"
 	"github.com/Kavantix/lazysql/internal/gui"
+	"github.com/Kavantix/lazysql/internal/sshtunnel"
 )
 
 type baseContext interface {
 	gui.Context
 	LayoutPopupView()
 	InitPopupView()
-	ShowDatabaseLayout(database.Driver, []database.Database)
+	ShowDatabaseLayout(database.Driver, []database.Database, gui.Selection)
 }
 
 func Show(context baseContext) {
 	g := context.Gui()
 	configPane, err := NewConfigPane(func(host Host) {
-		context.Log(fmt.Sprintf("Connecting to %s %s:%d", host.DbType, host.Host, host.Port))
-		dsn := database.Dsn{
-			Host:     host.Host,
-			Port:     uint16(host.Port),
-			User:     host.User,
-			Password: host.Password,
-		}
-		var db database.Driver
-		var err error
-		switch host.DbType {
-		case "mysql":
-			db, err = mysqldriver.NewMysqlDriver(dsn)
-		case "postgresql":
-			db, err = pgxdriver.NewPgxDriver(dsn)
-		default:
-			err = fmt.Errorf("No driver for type: %s", host.DbType)
-		}
-		if err != nil {
-			context.ShowError(err.Error())
-			return
-		}
-		context.Log(fmt.Sprintf("Connected to %s %s:%d", host.DbType, host.Host, host.Port))
-		databases, err := db.Databases()
-		if err != nil {
-			context.ShowError(err.Error())
-		} else {
-			context.ShowDatabaseLayout(db, databases)
-		}
+		Unlock(context, host, func() {
+			db, databases, err := Connect(context, host)
+			if err != nil {
+				context.ShowError(err.Error())
+				return
+			}
+			context.ShowDatabaseLayout(db, databases, gui.Selection{})
+		})
 	},
 		context,
 	)
@@ -68,6 +48,70 @@ func Show(context baseContext) {
 	checkErr(err)
 }
 
+// Connect opens a connection to host and lists its databases,
+// Unlock has to be called first when host uses the secret store
+func Connect(context gui.Context, host Host) (database.Driver, []database.Database, error) {
+	info, err := database.LookupDriver(host.DbType)
+	if err != nil {
+		return nil, nil, err
+	}
+	host, err = host.withSecrets()
+	if err != nil {
+		return nil, nil, err
+	}
+	host, sources, err := host.withCredentials(credentials.DefaultEnvironment())
+	if err != nil {
+		return nil, nil, err
+	}
+	for field, source := range sources {
+		context.Log(fmt.Sprintf("Using %s from %s", field, source))
+	}
+	host = host.withDefaults(info)
+	context.Log(fmt.Sprintf("Connecting to %s %s", host.DbType, host.Address()))
+	dsn := database.Dsn{
+		Host:     host.Host,
+		Port:     uint16(host.Port),
+		User:     host.User,
+		Password: host.Password,
+		File:     host.File,
+	}
+	if err := info.CheckRequired(dsn); err != nil {
+		return nil, nil, err
+	}
+	dsn.QueryTimeout, err = host.queryTimeout()
+	if err != nil {
+		return nil, nil, err
+	}
+	if host.TLS != nil {
+		dsn.TLS, err = host.TLS.config()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if host.SSH != nil {
+		context.Log(fmt.Sprintf("Opening ssh tunnel through %s", host.SSH.Address()))
+		tunnel, err := sshtunnel.Open(host.SSH.config())
+		if err != nil {
+			return nil, nil, err
+		}
+		dsn.Dialer = tunnel
+	}
+	db, err := info.New(dsn)
+	if err != nil {
+		if dsn.Dialer != nil {
+			dsn.Dialer.Close()
+		}
+		return nil, nil, err
+	}
+	context.Log(fmt.Sprintf("Connected to %s %s", host.DbType, host.Address()))
+	databases, err := db.Databases()
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return db, databases, nil
+}
+
 func checkErr(err error) {
 	if err != nil {
 		log.Panicln(err)
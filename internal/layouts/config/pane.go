@@ -7,11 +7,22 @@ import (
 	"strings"
 
 	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/credentials"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/database/drivers/pgxdriver"
 	"github.com/Kavantix/lazysql/internal/gui"
+	"github.com/Kavantix/lazysql/internal/secretstore"
 )
 
 const newHostName = "  << New host >>  "
 
+// formHeight is the amount of rows from the top of the host form to the buttons below it
+const formHeight = 26
+
+// minHostsHeight is the least amount of rows the hosts get above the form,
+// on shorter terminals they are listed next to it
+const minHostsHeight = 6
+
 func (h *Host) String() string {
 	return h.Name
 }
@@ -34,9 +45,19 @@ type ConfigPane struct {
 	dbTypeTextBox                         *textBox
 	nameTextBox, hostTextBox, portTextBox *textBox
 	userTextBox, passwordTextBox          *textBox
+	passwordCommandTextBox                *textBox
+	queryTimeoutTextBox                   *textBox
+	sshTextBox, sshKeyTextBox             *textBox
+	tlsModeTextBox, tlsServerNameTextBox  *textBox
+	tlsCATextBox, tlsCertTextBox          *textBox
+	tlsKeyTextBox                         *textBox
 	connectButton, saveButton             *button
 	hostsPane                             *gui.Pane[*Host]
 	hosts                                 []*Host
+	// resolved is the selected host with the settings it leaves empty resolved,
+	// sources describes where those were found
+	resolved Host
+	sources  map[credentials.Field]string
 }
 
 func NewConfigPane(onConnect func(host Host), context gui.Context) (*ConfigPane, error) {
@@ -127,16 +148,30 @@ func (c *ConfigPane) Init(g *gocui.Gui) error {
 	g.SetKeybinding(c.hostsPane.Name, 'q', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
 		return gocui.ErrQuit
 	})
+	g.SetKeybinding(c.hostsPane.Name, 'E', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		c.encryptPasswords()
+		return nil
+	})
 
-	c.dbTypeTextBox, _ = newTextBox(g, "Type (postgresql, mysql)", "postgresql", false, c.selectHostsPane, c.selectNameTextbox, c.selectHostsPane)
+	typeTitle := fmt.Sprintf("Type (%s)", strings.Join(database.DriverNames(), ", "))
+	c.dbTypeTextBox, _ = newTextBox(g, typeTitle, pgxdriver.Name, false, c.selectHostsPane, c.selectNameTextbox, c.selectHostsPane)
 	c.nameTextBox, _ = newTextBox(g, "Name", "", false, c.selectDbTypeTextBox, c.selectHostTextbox, c.selectHostsPane)
 	c.hostTextBox, _ = newTextBox(g, "Host", "", false, c.selectNameTextbox, c.selectPort, c.selectHostsPane)
-	c.portTextBox, _ = newTextBox(g, "Port", "", false, c.selectHostTextbox, c.selectUser, c.selectHostsPane)
-	c.userTextBox, _ = newTextBox(g, "Username", "", false, c.selectPort, c.selectPassword, c.selectHostsPane)
-	c.passwordTextBox, _ = newTextBox(g, "Password", "", true, c.selectUser, c.selectConnect, c.selectHostsPane)
+	c.portTextBox, _ = newTextBox(g, "Port", "", false, c.selectHostTextbox, c.selectQueryTimeout, c.selectHostsPane)
+	c.queryTimeoutTextBox, _ = newTextBox(g, "Query timeout (e.g. 30s)", "", false, c.selectPort, c.selectUser, c.selectHostsPane)
+	c.userTextBox, _ = newTextBox(g, "Username", "", false, c.selectQueryTimeout, c.selectPassword, c.selectHostsPane)
+	c.passwordTextBox, _ = newTextBox(g, "Password", "", true, c.selectUser, c.selectPasswordCommand, c.selectHostsPane)
+	c.passwordCommandTextBox, _ = newTextBox(g, "Password command", "", false, c.selectPassword, c.selectSSH, c.selectHostsPane)
+	c.sshTextBox, _ = newTextBox(g, "SSH tunnel (user@host:port)", "", false, c.selectPasswordCommand, c.selectSSHKey, c.selectHostsPane)
+	c.sshKeyTextBox, _ = newTextBox(g, "SSH key file (empty uses the agent)", "", false, c.selectSSH, c.selectTLSMode, c.selectHostsPane)
+	c.tlsModeTextBox, _ = newTextBox(g, "TLS mode (disable, prefer, require, verify-ca, verify-full)", "", false, c.selectSSHKey, c.selectTLSServerName, c.selectHostsPane)
+	c.tlsServerNameTextBox, _ = newTextBox(g, "TLS server name (empty uses the host)", "", false, c.selectTLSMode, c.selectTLSCA, c.selectHostsPane)
+	c.tlsCATextBox, _ = newTextBox(g, "CA bundle", "", false, c.selectTLSServerName, c.selectTLSCert, c.selectHostsPane)
+	c.tlsCertTextBox, _ = newTextBox(g, "Client certificate", "", false, c.selectTLSCA, c.selectTLSKey, c.selectHostsPane)
+	c.tlsKeyTextBox, _ = newTextBox(g, "Client key", "", false, c.selectTLSCert, c.selectConnect, c.selectHostsPane)
 
 	c.connectButton, _ = newButton(g, "Connect",
-		c.selectPassword,
+		c.selectTLSKey,
 		c.selectSave,
 		func() {
 			host, err := c.hostFromTextBoxes()
@@ -184,6 +219,10 @@ func (c *ConfigPane) selectPort() {
 	c.g.SetCurrentView(c.portTextBox.Name)
 }
 
+func (c *ConfigPane) selectQueryTimeout() {
+	c.g.SetCurrentView(c.queryTimeoutTextBox.Name)
+}
+
 func (c *ConfigPane) selectUser() {
 	c.g.SetCurrentView(c.userTextBox.Name)
 }
@@ -192,6 +231,38 @@ func (c *ConfigPane) selectPassword() {
 	c.g.SetCurrentView(c.passwordTextBox.Name)
 }
 
+func (c *ConfigPane) selectPasswordCommand() {
+	c.g.SetCurrentView(c.passwordCommandTextBox.Name)
+}
+
+func (c *ConfigPane) selectSSH() {
+	c.g.SetCurrentView(c.sshTextBox.Name)
+}
+
+func (c *ConfigPane) selectSSHKey() {
+	c.g.SetCurrentView(c.sshKeyTextBox.Name)
+}
+
+func (c *ConfigPane) selectTLSMode() {
+	c.g.SetCurrentView(c.tlsModeTextBox.Name)
+}
+
+func (c *ConfigPane) selectTLSServerName() {
+	c.g.SetCurrentView(c.tlsServerNameTextBox.Name)
+}
+
+func (c *ConfigPane) selectTLSCA() {
+	c.g.SetCurrentView(c.tlsCATextBox.Name)
+}
+
+func (c *ConfigPane) selectTLSCert() {
+	c.g.SetCurrentView(c.tlsCertTextBox.Name)
+}
+
+func (c *ConfigPane) selectTLSKey() {
+	c.g.SetCurrentView(c.tlsKeyTextBox.Name)
+}
+
 func (c *ConfigPane) selectConnect() {
 	c.g.SetCurrentView(c.connectButton.Name)
 }
@@ -200,32 +271,79 @@ func (c *ConfigPane) selectSave() {
 	c.g.SetCurrentView(c.saveButton.Name)
 }
 
+// usesFile reports whether the type in the type textbox is a file based database
+// in which case the host textbox is used for the path of the database file
+func (c *ConfigPane) usesFile() bool {
+	info, err := database.LookupDriver(c.dbTypeTextBox.content)
+	return err == nil && info.Uses(database.FieldFile)
+}
+
 func (c *ConfigPane) hostFromTextBoxes() (Host, error) {
-	port, err := strconv.Atoi(c.portTextBox.content)
-	if err != nil || port < 1 || port > 65535 {
-		return Host{}, errors.New("port should be a valid integer between 1 and 65535")
-	}
-	dbType := ""
-	switch strings.TrimSpace(c.dbTypeTextBox.content) {
-	case "postgres", "postgresql":
-		dbType = "postgresql"
-	case "mysql":
-		dbType = "mysql"
-	default:
-		return Host{}, fmt.Errorf("Unknown type, should be one of (postgresql, mysql)")
+	info, err := database.LookupDriver(c.dbTypeTextBox.content)
+	if err != nil {
+		return Host{}, err
 	}
 	host := Host{
-		DbType:   dbType,
-		Name:     strings.TrimSpace(c.nameTextBox.content),
-		Host:     strings.TrimSpace(c.hostTextBox.content),
-		Port:     port,
-		User:     strings.TrimSpace(c.userTextBox.content),
-		Password: strings.TrimSpace(c.passwordTextBox.content),
+		DbType:       info.Name,
+		Name:         strings.TrimSpace(c.nameTextBox.content),
+		QueryTimeout: strings.TrimSpace(c.queryTimeoutTextBox.content),
 	}
-
 	if host.Name == "" {
 		return Host{}, errors.New("Host name cannot be empty")
 	}
+	if _, err := host.queryTimeout(); err != nil {
+		return Host{}, err
+	}
+	if info.Uses(database.FieldFile) {
+		host.File = strings.TrimSpace(c.hostTextBox.content)
+		if err := info.CheckRequired(database.Dsn{File: host.File}); err != nil {
+			return Host{}, err
+		}
+		return host, nil
+	}
+	port := 0
+	if content := strings.TrimSpace(c.portTextBox.content); content != "" {
+		var err error
+		port, err = strconv.Atoi(content)
+		if err != nil || port < 1 || port > 65535 {
+			return Host{}, errors.New("port should be a valid integer between 1 and 65535")
+		}
+	}
+	host.Host = strings.TrimSpace(c.hostTextBox.content)
+	host.Port = port
+	host.User = strings.TrimSpace(c.userTextBox.content)
+	host.Password = strings.TrimSpace(c.passwordTextBox.content)
+	// References and commands are kept as is and only resolved when connecting
+	host.PasswordCommand = strings.TrimSpace(c.passwordCommandTextBox.content)
+	if c.selectedHost != nil {
+		// The service can only be configured in the config file
+		host.Service = c.selectedHost.Service
+	}
+	if address := strings.TrimSpace(c.sshTextBox.content); address != "" {
+		var err error
+		host.SSH, err = parseSSHAddress(address)
+		if err != nil {
+			return Host{}, err
+		}
+		host.SSH.KeyFile = strings.TrimSpace(c.sshKeyTextBox.content)
+		if c.selectedHost != nil && c.selectedHost.SSH != nil {
+			// The known hosts file can only be configured in the config file
+			host.SSH.KnownHosts = c.selectedHost.SSH.KnownHosts
+		}
+	}
+	tls := &HostTLS{
+		Mode:       strings.TrimSpace(c.tlsModeTextBox.content),
+		ServerName: strings.TrimSpace(c.tlsServerNameTextBox.content),
+		CA:         strings.TrimSpace(c.tlsCATextBox.content),
+		Cert:       strings.TrimSpace(c.tlsCertTextBox.content),
+		Key:        strings.TrimSpace(c.tlsKeyTextBox.content),
+	}
+	if *tls != (HostTLS{}) {
+		if _, err := tls.config(); err != nil {
+			return Host{}, err
+		}
+		host.TLS = tls
+	}
 	return host, nil
 }
 
@@ -252,8 +370,41 @@ func (c *ConfigPane) onSave() {
 	c.context.ShowSuccess("Saved hosts")
 }
 
+// encryptPasswords moves the plaintext passwords of all hosts to the secret store after confirming
+func (c *ConfigPane) encryptPasswords() {
+	count := 0
+	for _, host := range c.hosts {
+		if isPlaintextPassword(host.Password) {
+			count += 1
+		}
+	}
+	if count == 0 {
+		c.context.ShowInfo("There are no plaintext passwords to encrypt")
+		return
+	}
+	path, err := secretStorePath()
+	if c.context.HandleError(err) {
+		return
+	}
+	c.context.Confirm(fmt.Sprintf("Move the plaintext passwords of %d hosts to the encrypted secret store?", count), func() error {
+		unlockStore(c.context, path, func(store *secretstore.Store) {
+			moved, err := encryptPasswords(c.hosts, store)
+			if c.context.HandleError(err) || c.context.HandleError(SaveHosts(c.hosts)) {
+				return
+			}
+			if c.selectedHost != nil {
+				c.changeHost(c.selectedHost)
+			}
+			c.context.ShowSuccess(fmt.Sprintf("Moved %d passwords to %s", moved, path))
+		})
+		return nil
+	})
+}
+
 func (c *ConfigPane) changeHost(host *Host) {
 	c.selectedHost = host
+	// Errors are shown when connecting
+	c.resolved, c.sources, _ = host.withCredentials(credentials.DefaultEnvironment())
 	if host.Name == newHostName {
 		c.dbTypeTextBox.SetContent("")
 		c.nameTextBox.SetContent("")
@@ -267,9 +418,66 @@ func (c *ConfigPane) changeHost(host *Host) {
 			c.portTextBox.SetContent(strconv.Itoa(host.Port))
 		}
 	}
-	c.hostTextBox.SetContent(host.Host)
+	if host.usesFile() {
+		c.hostTextBox.SetContent(host.File)
+	} else {
+		c.hostTextBox.SetContent(host.Host)
+	}
 	c.userTextBox.SetContent(host.User)
 	c.passwordTextBox.SetContent(host.Password)
+	c.passwordCommandTextBox.SetContent(host.PasswordCommand)
+	c.queryTimeoutTextBox.SetContent(host.QueryTimeout)
+	if host.SSH != nil {
+		c.sshTextBox.SetContent(host.SSH.Address())
+		c.sshKeyTextBox.SetContent(host.SSH.KeyFile)
+	} else {
+		c.sshTextBox.SetContent("")
+		c.sshKeyTextBox.SetContent("")
+	}
+	tls := HostTLS{}
+	if host.TLS != nil {
+		tls = *host.TLS
+	}
+	c.tlsModeTextBox.SetContent(tls.Mode)
+	c.tlsServerNameTextBox.SetContent(tls.ServerName)
+	c.tlsCATextBox.SetContent(tls.CA)
+	c.tlsCertTextBox.SetContent(tls.Cert)
+	c.tlsKeyTextBox.SetContent(tls.Key)
+}
+
+// titleWithSource adds where the value of field comes from to title when it is not configured,
+// value is shown too when it is not empty
+func (c *ConfigPane) titleWithSource(title string, field credentials.Field, value string) string {
+	source, ok := c.sources[field]
+	if !ok {
+		return title
+	}
+	if value != "" {
+		return fmt.Sprintf("%s (%s from %s)", title, value, source)
+	}
+	return fmt.Sprintf("%s (from %s)", title, source)
+}
+
+// layoutForm places the fields of the host between left and right starting at top
+func (c *ConfigPane) layoutForm(left, top, right int) {
+	half := (left + right) / 2
+	third := (right - left) / 3
+	typeRight := min(left+32, half-1)
+	c.dbTypeTextBox.Layout(left, top, typeRight, top+2)
+	c.nameTextBox.Layout(typeRight+2, top, right, top+2)
+	c.hostTextBox.Layout(left, top+3, right, top+5)
+	c.portTextBox.Layout(left, top+6, half-1, top+8)
+	c.queryTimeoutTextBox.Layout(half+1, top+6, right, top+8)
+	c.userTextBox.Layout(left, top+9, right, top+11)
+	c.passwordTextBox.Layout(left, top+12, half-1, top+14)
+	c.passwordCommandTextBox.Layout(half+1, top+12, right, top+14)
+	c.sshTextBox.Layout(left, top+15, half-1, top+17)
+	c.sshKeyTextBox.Layout(half+1, top+15, right, top+17)
+	c.tlsModeTextBox.Layout(left, top+18, half-1, top+20)
+	c.tlsServerNameTextBox.Layout(half+1, top+18, right, top+20)
+	c.tlsCATextBox.Layout(left, top+21, left+third-1, top+23)
+	c.tlsCertTextBox.Layout(left+third+1, top+21, left+third*2-1, top+23)
+	c.tlsKeyTextBox.Layout(left+third*2+1, top+21, right, top+23)
 }
 
 func (c *ConfigPane) Layout(g *gocui.Gui) error {
@@ -284,18 +492,34 @@ func (c *ConfigPane) Layout(g *gocui.Gui) error {
 	if err != nil {
 		panic(err)
 	}
-	start := maxY - 2 - footerHeight - 23 + 3
-	c.dbTypeTextBox.Layout(6, start, 6+32, start+2)
-	c.nameTextBox.Layout(6+32+2, start, maxX-6, start+2)
-	c.hostTextBox.Layout(6, start+3, maxX-6, start+5)
-	c.portTextBox.Layout(6, start+6, maxX-6, start+8)
-	c.userTextBox.Layout(6, start+9, maxX-6, start+11)
-	c.passwordTextBox.Layout(6, start+12, maxX-6, start+14)
-
-	c.connectButton.layout(maxX/3, maxY-5-footerHeight)
-	c.saveButton.layout(maxX/3*2, maxY-5-footerHeight)
-
-	c.hostsPane.Position(6, 4, maxX-6, maxY-2-22-footerHeight)
+	if c.usesFile() {
+		c.hostTextBox.view.Title = "File"
+	} else {
+		c.hostTextBox.view.Title = c.titleWithSource("Host", credentials.FieldHost, c.resolved.Host)
+	}
+	port := ""
+	if c.resolved.Port != 0 {
+		port = strconv.Itoa(c.resolved.Port)
+	}
+	c.portTextBox.view.Title = c.titleWithSource("Port", credentials.FieldPort, port)
+	c.userTextBox.view.Title = c.titleWithSource("Username", credentials.FieldUser, c.resolved.User)
+	c.passwordTextBox.view.Title = c.titleWithSource("Password", credentials.FieldPassword, "")
+
+	buttonsY := maxY - 5 - footerHeight
+	start := buttonsY - formHeight
+	if start-6 >= minHostsHeight {
+		c.layoutForm(6, start, maxX-6)
+		c.connectButton.layout(maxX/3, buttonsY)
+		c.saveButton.layout(maxX/3*2, buttonsY)
+		c.hostsPane.Position(6, 4, maxX-6, start-2)
+	} else {
+		// The terminal is too short to list the hosts above the form
+		left := maxX/2 + 1
+		c.layoutForm(left, 4, maxX-6)
+		c.connectButton.layout(left+(maxX-6-left)/3, buttonsY)
+		c.saveButton.layout(left+(maxX-6-left)*2/3, buttonsY)
+		c.hostsPane.Position(6, 4, maxX/2-1, maxY-3-footerHeight)
+	}
 	c.hostsPane.Paint()
 
 	return nil
@@ -0,0 +1,259 @@
+package _configLayout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/gui"
+	"github.com/Kavantix/lazysql/internal/secretstore"
+)
+
+// passwordCommandTimeout limits how long a password command can take, e.g. to unlock a password manager
+const passwordCommandTimeout = 30 * time.Second
+
+// secretPrefix marks references to secrets in the encrypted store, like ${secret:prod}
+const secretPrefix = "secret:"
+
+// escapedReference is how a literal ${ is written in a password
+const escapedReference = "$${"
+
+// reference matches the references in a password and the escaped ${ that are not references
+var reference = regexp.MustCompile(`\$\$\{|\$\{([^}]*)\}`)
+
+// references returns the names of the references in value, escaped ${ are skipped
+func references(value string) []string {
+	names := []string{}
+	for _, match := range reference.FindAllStringSubmatch(value, -1) {
+		if match[0] != escapedReference {
+			names = append(names, match[1])
+		}
+	}
+	return names
+}
+
+var (
+	storeMutex sync.Mutex
+	// store is the secret store once it is unlocked, the passphrase is only asked once per session
+	store *secretstore.Store
+)
+
+func unlockedStore() *secretstore.Store {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	return store
+}
+
+func setUnlockedStore(unlocked *secretstore.Store) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	store = unlocked
+}
+
+func secretStorePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return pathTo(dir, "secrets.json"), nil
+}
+
+// usesSecretStore reports whether h references secrets in the encrypted store
+func (h Host) usesSecretStore() bool {
+	for _, name := range references(h.Password) {
+		if strings.HasPrefix(name, secretPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// NeedsUnlock reports whether the passphrase of the secret store has to be asked before connecting to host
+func NeedsUnlock(host Host) bool {
+	return host.usesSecretStore() && unlockedStore() == nil
+}
+
+// Unlock asks for the passphrase of the secret store when host uses it and it is still locked,
+// onUnlocked is called once the secrets of host can be resolved
+func Unlock(context gui.Context, host Host, onUnlocked func()) {
+	if !NeedsUnlock(host) {
+		onUnlocked()
+		return
+	}
+	path, err := secretStorePath()
+	if context.HandleError(err) {
+		return
+	}
+	if !secretstore.Exists(path) {
+		context.ShowError(fmt.Sprintf("%s uses the secret store but %s does not exist", host.Name, path))
+		return
+	}
+	unlockStore(context, path, func(*secretstore.Store) {
+		onUnlocked()
+	})
+}
+
+// unlockStore asks for the passphrase of the store at path, or a new passphrase if it does not exist yet
+func unlockStore(context gui.Context, path string, onUnlocked func(store *secretstore.Store)) {
+	if unlocked := unlockedStore(); unlocked != nil {
+		onUnlocked(unlocked)
+		return
+	}
+	message := "Passphrase of the secret store"
+	if !secretstore.Exists(path) {
+		message = "Choose a passphrase for the new secret store"
+	}
+	context.Prompt("Secret store", message, true, func(passphrase string) {
+		unlocked, err := secretstore.Open(path, passphrase)
+		if context.HandleError(err) {
+			return
+		}
+		setUnlockedStore(unlocked)
+		onUnlocked(unlocked)
+	})
+}
+
+// isPlaintextPassword reports whether password is a literal secret instead of a reference
+func isPlaintextPassword(password string) bool {
+	return password != "" && len(references(password)) == 0
+}
+
+// secretName returns the name to store password of host under, a } would end its reference
+// A number is added when the name is taken by another secret so it is never overwritten
+func secretName(host *Host, password string, store *secretstore.Store) string {
+	base := strings.ReplaceAll(host.Name, "}", "")
+	name := base
+	for i := 2; ; i++ {
+		secret, taken := store.Get(name)
+		if !taken || secret == password {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// encryptPasswords moves the plaintext passwords of hosts to the secret store,
+// replacing them with references to their secret
+func encryptPasswords(hosts []*Host, store *secretstore.Store) (int, error) {
+	names := map[*Host]string{}
+	for _, host := range hosts {
+		if isPlaintextPassword(host.Password) {
+			// Secrets are used as they are, so the escaped ${ are stored as ${
+			password := strings.ReplaceAll(host.Password, escapedReference, "${")
+			names[host] = secretName(host, password, store)
+			store.Set(names[host], password)
+		}
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+	// The secrets have to be stored before the passwords are removed from the hosts
+	if err := store.Save(); err != nil {
+		return 0, err
+	}
+	for host, name := range names {
+		host.Password = "${" + secretPrefix + name + "}"
+	}
+	return len(names), nil
+}
+
+// withSecrets returns h with its password resolved from the password command or references
+// Hosts are only resolved when connecting so the resolved secrets are never shown or saved
+func (h Host) withSecrets() (Host, error) {
+	if h.PasswordCommand != "" {
+		if h.Password != "" {
+			return h, errors.New("set either a password or a password command, not both")
+		}
+		password, err := runPasswordCommand(h.PasswordCommand)
+		if err != nil {
+			return h, err
+		}
+		h.Password = password
+		return h, nil
+	}
+	password, err := expandReferences(h.Password)
+	if err != nil {
+		return h, err
+	}
+	h.Password = password
+	return h, nil
+}
+
+// expandReferences replaces every ${NAME} in value with the environment variable NAME
+// and every ${secret:NAME} with the secret NAME from the secret store, $${ is replaced by a literal ${
+func expandReferences(value string) (string, error) {
+	var err error
+	expanded := reference.ReplaceAllStringFunc(value, func(match string) string {
+		if match == escapedReference {
+			return "${"
+		}
+		resolved, lookupErr := lookupReference(reference.FindStringSubmatch(match)[1])
+		if lookupErr != nil && err == nil {
+			err = lookupErr
+		}
+		return resolved
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+func lookupReference(name string) (string, error) {
+	if secretName, ok := strings.CutPrefix(name, secretPrefix); ok {
+		unlocked := unlockedStore()
+		if unlocked == nil {
+			return "", errors.New("the secret store is locked")
+		}
+		secret, ok := unlocked.Get(secretName)
+		if !ok {
+			return "", fmt.Errorf("no secret named %s in the secret store", secretName)
+		}
+		return secret, nil
+	}
+	resolved, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return resolved, nil
+}
+
+// runPasswordCommand runs command in a shell and returns the first line it prints,
+// which is where tools like pass put the password
+func runPasswordCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), passwordCommandTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	stderr := bytes.Buffer{}
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("password command timed out after %s", passwordCommandTimeout)
+	}
+	if err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("password command failed: %s", message)
+	}
+	password, _, _ := strings.Cut(string(output), "\n")
+	password = strings.TrimSuffix(password, "\r")
+	if password == "" {
+		return "", errors.New("password command did not print a password")
+	}
+	return password, nil
+}
@@ -0,0 +1,209 @@
+package _configLayout
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Kavantix/lazysql/internal/secretstore"
+)
+
+func TestPasswordReferences(t *testing.T) {
+	t.Setenv("LAZYSQL_TEST_PASSWORD", "s3cret")
+	host := Host{Name: "prod", Password: "${LAZYSQL_TEST_PASSWORD}"}
+	resolved, err := host.withSecrets()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved.Password != "s3cret" {
+		t.Fatalf("Password was not resolved: `%s`", resolved.Password)
+	}
+	if host.Password != "${LAZYSQL_TEST_PASSWORD}" {
+		t.Fatalf("Resolving changed the host: `%s`", host.Password)
+	}
+
+	// Passwords without references are left alone, including a single $
+	resolved, err = Host{Password: "pa$word"}.withSecrets()
+	if err != nil || resolved.Password != "pa$word" {
+		t.Fatalf("Literal password changed: `%s`, %v", resolved.Password, err)
+	}
+
+	_, err = Host{Password: "${LAZYSQL_TEST_MISSING}"}.withSecrets()
+	if err == nil || !strings.Contains(err.Error(), "LAZYSQL_TEST_MISSING") {
+		t.Fatalf("Expected an error for a missing variable, got %v", err)
+	}
+}
+
+func TestEscapedReferences(t *testing.T) {
+	t.Setenv("cd", "expanded")
+	resolved, err := Host{Password: "ab$${cd}"}.withSecrets()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved.Password != "ab${cd}" {
+		t.Fatalf("Escaped reference should be a literal ${, got `%s`", resolved.Password)
+	}
+	resolved, err = Host{Password: "$${x}-${cd}-$$"}.withSecrets()
+	if err != nil || resolved.Password != "${x}-expanded-$$" {
+		t.Fatalf("Expected only the unescaped reference to be expanded, got `%s`, %v", resolved.Password, err)
+	}
+	if !isPlaintextPassword("ab$${cd}") || NeedsUnlock(Host{Password: "$${secret:prod}"}) {
+		t.Fatalf("A password with only escaped references is plaintext")
+	}
+
+	unlocked, err := secretstore.Open(filepath.Join(t.TempDir(), "secrets.json"), "passphrase")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hosts := []*Host{{Name: "prod", Password: "ab$${cd}"}}
+	if _, err := encryptPasswords(hosts, unlocked); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if secret, _ := unlocked.Get("prod"); secret != "ab${cd}" || hosts[0].Password != "${secret:prod}" {
+		t.Fatalf("Expected the literal password in the store, got `%s` referenced by `%s`", secret, hosts[0].Password)
+	}
+}
+
+func TestPasswordCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	resolved, err := Host{PasswordCommand: "printf 'from-command\\nlogin: me\\n'"}.withSecrets()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved.Password != "from-command" {
+		t.Fatalf("Expected the first line of the output, got `%s`", resolved.Password)
+	}
+
+	_, err = Host{PasswordCommand: "echo locked >&2; exit 1"}.withSecrets()
+	if err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Fatalf("Expected the error output of the command, got %v", err)
+	}
+
+	_, err = Host{Password: "literal", PasswordCommand: "echo other"}.withSecrets()
+	if err == nil {
+		t.Fatalf("Expected an error when both a password and command are set")
+	}
+}
+
+func TestMarshalKeepsReferences(t *testing.T) {
+	t.Setenv("LAZYSQL_TEST_PASSWORD", "s3cret")
+	yaml := `
+hosts:
+  prod:
+    user: app
+    password: ${LAZYSQL_TEST_PASSWORD}
+  staging:
+    user: app
+    passwordCommand: pass show db/staging
+`[1:]
+	hosts, err := unmarshalHosts([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Unexpected error while ummarshaling %s", err)
+	}
+	if output := marshalHosts(hosts); output != yaml {
+		t.Fatalf("References were not kept!\n output yaml:`\n%s`\ninstead of:`\n%s`", output, yaml)
+	}
+}
+
+func TestSecretStoreReferences(t *testing.T) {
+	unlocked, err := secretstore.Open(filepath.Join(t.TempDir(), "secrets.json"), "passphrase")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	unlocked.Set("prod", "from-store")
+	host := Host{Name: "prod", Password: "${secret:prod}"}
+
+	setUnlockedStore(nil)
+	if !NeedsUnlock(host) {
+		t.Fatalf("A host using the store needs it to be unlocked")
+	}
+	if NeedsUnlock(Host{Password: "${LAZYSQL_TEST_PASSWORD}"}) {
+		t.Fatalf("Only secret references need the store")
+	}
+	if _, err := host.withSecrets(); err == nil {
+		t.Fatalf("Expected an error while the store is locked")
+	}
+
+	setUnlockedStore(unlocked)
+	defer setUnlockedStore(nil)
+	resolved, err := host.withSecrets()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if resolved.Password != "from-store" {
+		t.Fatalf("Secret was not resolved: `%s`", resolved.Password)
+	}
+	if _, err := (Host{Password: "${secret:missing}"}).withSecrets(); err == nil {
+		t.Fatalf("Expected an error for a missing secret")
+	}
+}
+
+func TestEncryptPasswords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	unlocked, err := secretstore.Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hosts := []*Host{
+		{Name: "plain", Password: "hunter2"},
+		{Name: "env", Password: "${DB_PASSWORD}"},
+		{Name: "command", PasswordCommand: "pass show db"},
+	}
+	moved, err := encryptPasswords(hosts, unlocked)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if moved != 1 {
+		t.Fatalf("Only the plaintext password should be moved, moved %d", moved)
+	}
+	if hosts[0].Password != "${secret:plain}" || hosts[1].Password != "${DB_PASSWORD}" || hosts[2].Password != "" {
+		t.Fatalf("Wrong passwords after encrypting: %s, %s, %s", hosts[0].Password, hosts[1].Password, hosts[2].Password)
+	}
+
+	reopened, err := secretstore.Open(path, "passphrase")
+	if err != nil {
+		t.Fatalf("Store was not saved: %s", err)
+	}
+	if secret, _ := reopened.Get("plain"); secret != "hunter2" {
+		t.Fatalf("Wrong secret in store: `%s`", secret)
+	}
+}
+
+func TestEncryptPasswordsKeepsOtherSecrets(t *testing.T) {
+	unlocked, err := secretstore.Open(filepath.Join(t.TempDir(), "secrets.json"), "passphrase")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	unlocked.Set("prod", "encrypted-before")
+	unlocked.Set("staging", "same")
+	hosts := []*Host{
+		{Name: "prod", Password: "new-prod"},
+		{Name: "a}b", Password: "first"},
+		{Name: "ab", Password: "second"},
+		{Name: "staging", Password: "same"},
+	}
+	if _, err := encryptPasswords(hosts, unlocked); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := []string{"${secret:prod-2}", "${secret:ab}", "${secret:ab-2}", "${secret:staging}"}
+	for i, host := range hosts {
+		if host.Password != expected[i] {
+			t.Fatalf("Expected %s for %s, got %s", expected[i], host.Name, host.Password)
+		}
+	}
+	for name, secret := range map[string]string{
+		"prod":    "encrypted-before",
+		"prod-2":  "new-prod",
+		"ab":      "first",
+		"ab-2":    "second",
+		"staging": "same",
+	} {
+		if stored, _ := unlocked.Get(name); stored != secret {
+			t.Fatalf("Expected secret %s to be `%s`, got `%s`", name, secret, stored)
+		}
+	}
+}
@@ -3,60 +3,250 @@ package _configLayout
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io/fs"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Kavantix/lazysql/internal/credentials"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/database/drivers/pgxdriver"
+	"github.com/Kavantix/lazysql/internal/sshtunnel"
 	"gopkg.in/yaml.v3"
 )
 
 type Host struct {
-	Name     string
-	DbType   string `yaml:"dbType"`
-	Host     string `yaml:"host"`
-	Port     int    `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
+	Name   string
+	DbType string `yaml:"dbType,omitempty"`
+	Host   string `yaml:"host,omitempty"`
+	Port   int    `yaml:"port,omitempty"`
+	User   string `yaml:"user,omitempty"`
+	// Password can contain references to environment variables like ${DB_PASSWORD}
+	Password string `yaml:"password,omitempty"`
+	// PasswordCommand is run in a shell when connecting to get the password, e.g. pass show db/prod
+	PasswordCommand string `yaml:"passwordCommand,omitempty"`
+	// File is the path of the database file, only used by sqlite
+	File string `yaml:"file,omitempty"`
+	// Service is the section of the postgres service file to read the connection settings from
+	Service string `yaml:"service,omitempty"`
+	// SSH is the ssh server to tunnel the connection through, nil to connect directly
+	SSH *SSHTunnel `yaml:"ssh,omitempty"`
+	// TLS configures the encryption of the connection, nil to use the default of the driver
+	TLS *HostTLS `yaml:"tls,omitempty"`
+	// QueryTimeout is how long a query can run before it is cancelled, e.g. 30s, empty for no timeout
+	QueryTimeout string `yaml:"queryTimeout,omitempty"`
 }
 
-func LoadHosts() ([]*Host, error) {
+// queryTimeout parses the query timeout of h, 0 when it has none
+func (h Host) queryTimeout() (time.Duration, error) {
+	if h.QueryTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(h.QueryTimeout)
+	if err != nil || timeout < 0 {
+		return 0, fmt.Errorf("query timeout should be a duration like 30s or 5m, got %s", h.QueryTimeout)
+	}
+	return timeout, nil
+}
+
+// HostTLS configures how the connection to a host is encrypted and verified
+type HostTLS struct {
+	// Mode is one of disable, prefer, require, verify-ca or verify-full
+	Mode string `yaml:"mode,omitempty"`
+	// CA is a PEM bundle of the authorities that sign the certificate of the server
+	CA         string `yaml:"ca,omitempty"`
+	Cert       string `yaml:"cert,omitempty"`
+	Key        string `yaml:"key,omitempty"`
+	ServerName string `yaml:"serverName,omitempty"`
+}
+
+func (t *HostTLS) config() (database.TLS, error) {
+	mode, err := database.ParseTLSMode(t.Mode)
+	if err != nil {
+		return database.TLS{}, err
+	}
+	return database.TLS{
+		Mode:       mode,
+		CAFile:     t.CA,
+		CertFile:   t.Cert,
+		KeyFile:    t.Key,
+		ServerName: t.ServerName,
+	}, nil
+}
+
+// withCredentials fills the empty connection settings of h from the files and environment variables
+// the command line client of its database uses, sources describes where each value was found
+func (h Host) withCredentials(env credentials.Environment) (Host, map[credentials.Field]string, error) {
+	c := credentials.Credentials{
+		Host:     h.Host,
+		Port:     h.Port,
+		User:     h.User,
+		Password: h.Password,
+		Service:  h.Service,
+	}
+	info, err := database.LookupDriver(h.DbType)
+	if err != nil || info.Credentials == nil {
+		// An unknown type is reported when connecting
+		return h, nil, nil
+	}
+	c, err = info.Credentials(c, env)
+	h.Host, h.Port, h.User, h.Password = c.Host, c.Port, c.User, c.Password
+	return h, c.Sources, err
+}
+
+// withDefaults sets the host and port that are still empty after resolving the credentials
+// to the defaults of the database of h
+func (h Host) withDefaults(info database.DriverInfo) Host {
+	if h.Host == "" && info.Uses(database.FieldHost) {
+		h.Host = "localhost"
+	}
+	if h.Port == 0 {
+		h.Port = int(info.DefaultPort)
+	}
+	return h
+}
+
+// SSHTunnel is the ssh server, like a bastion host, that the database is reached through
+type SSHTunnel struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port,omitempty"`
+	User string `yaml:"user,omitempty"`
+	// KeyFile is the private key to authenticate with, the ssh agent is used when empty
+	KeyFile string `yaml:"keyFile,omitempty"`
+	// KnownHosts is the file used to verify the ssh server, defaults to ~/.ssh/known_hosts
+	KnownHosts string `yaml:"knownHosts,omitempty"`
+}
+
+// usesFile reports whether the database of h is a file instead of a server
+func (h Host) usesFile() bool {
+	info, err := database.LookupDriver(h.DbType)
+	return err == nil && info.Uses(database.FieldFile)
+}
+
+// Address returns the tunnel as user@host:port, leaving out what is not set
+func (t *SSHTunnel) Address() string {
+	address := t.Host
+	if t.User != "" {
+		address = t.User + "@" + address
+	}
+	if t.Port != 0 {
+		address = fmt.Sprintf("%s:%d", address, t.Port)
+	}
+	return address
+}
+
+// parseSSHAddress parses an address of the form [user@]host[:port]
+func parseSSHAddress(address string) (*SSHTunnel, error) {
+	tunnel := &SSHTunnel{}
+	if user, rest, found := strings.Cut(address, "@"); found {
+		tunnel.User = user
+		address = rest
+	}
+	if host, port, found := strings.Cut(address, ":"); found {
+		number, err := strconv.Atoi(port)
+		if err != nil || number < 1 || number > 65535 {
+			return nil, errors.New("ssh port should be a valid integer between 1 and 65535")
+		}
+		tunnel.Port = number
+		address = host
+	}
+	if address == "" {
+		return nil, errors.New("ssh host cannot be empty")
+	}
+	tunnel.Host = address
+	return tunnel, nil
+}
+
+func (t *SSHTunnel) config() sshtunnel.Config {
+	return sshtunnel.Config{
+		Host:           t.Host,
+		Port:           t.Port,
+		User:           t.User,
+		KeyFile:        t.KeyFile,
+		KnownHostsFile: t.KnownHosts,
+	}
+}
+
+// Address returns a description of where the host is located for logging
+func (h Host) Address() string {
+	if h.usesFile() {
+		return h.File
+	}
+	if h.SSH != nil {
+		return fmt.Sprintf("%s:%d via %s", h.Host, h.Port, h.SSH.Address())
+	}
+	return fmt.Sprintf("%s:%d", h.Host, h.Port)
+}
+
+// configDir returns the directory the config is stored in,
+// it is made accessible only by the current user since it contains credentials
+func configDir() (string, error) {
 	homedir, err := os.UserHomeDir()
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	err = os.MkdirAll(pathTo(homedir, ".config", "lazysql"), os.ModePerm)
+	dir := pathTo(homedir, ".config", "lazysql")
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return "", err
+	}
+	// Older versions created the directory accessible by everyone
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func LoadHosts() ([]*Host, error) {
+	dir, err := configDir()
 	if err != nil {
 		return nil, err
 	}
-	filepath := pathTo(homedir, ".config", "lazysql", "hosts.yaml")
+	filepath := pathTo(dir, "hosts.yaml")
 	filecontent, err := os.ReadFile(filepath)
 	if err != nil {
 		if errors.Is(err, fs.ErrNotExist) {
-			return []*Host{
-				{
-					DbType: "postgresql",
-					Host:   "localhost",
-					Port:   5432,
-				},
-			}, nil
+			info, err := database.LookupDriver(pgxdriver.Name)
+			if err != nil {
+				return nil, err
+			}
+			host := Host{DbType: info.Name}.withDefaults(info)
+			return []*Host{&host}, nil
 		}
 		return nil, err
 	}
 	return unmarshalHosts([]byte(filecontent))
 }
 
+// FindHost returns the saved host named name
+func FindHost(name string) (*Host, error) {
+	hosts, err := LoadHosts()
+	if err != nil {
+		return nil, err
+	}
+	for _, host := range hosts {
+		if host.Name == name {
+			return host, nil
+		}
+	}
+	return nil, fmt.Errorf("no saved host named %s", name)
+}
+
 func SaveHosts(hosts []*Host) error {
-	homedir, err := os.UserHomeDir()
+	dir, err := configDir()
 	if err != nil {
 		return err
 	}
-	err = os.MkdirAll(pathTo(homedir, ".config", "lazysql"), os.ModePerm)
+	filepath := pathTo(dir, "hosts.yaml")
+	marshalledHosts := marshalHosts(hosts)
+	err = os.WriteFile(filepath, []byte(marshalledHosts), 0o600)
 	if err != nil {
 		return err
 	}
-	filepath := pathTo(homedir, ".config", "lazysql", "hosts.yaml")
-	marshalledHosts := marshalHosts(hosts)
-	err = os.WriteFile(filepath, []byte(marshalledHosts), os.ModePerm)
-	return err
+	// WriteFile keeps the permissions of an existing file
+	return os.Chmod(filepath, 0o600)
 }
 
 func marshalHosts(hosts []*Host) string {
@@ -127,9 +317,6 @@ func unmarshalHosts(filecontent []byte) (result []*Host, err error) {
 			return
 		}
 		result[i].Name = node.key
-		if len(result[i].Host) == 0 {
-			result[i].Host = "localhost"
-		}
 	}
 
 	return
@@ -1,7 +1,13 @@
 package _configLayout
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/Kavantix/lazysql/internal/credentials"
+	"github.com/Kavantix/lazysql/internal/database"
 )
 
 func TestUnmarshalHosts(t *testing.T) {
@@ -35,8 +41,9 @@ hosts:
 		t.Fatalf("Incorrect host1 parsed `%#v`", host1)
 	}
 	host2 := hosts[1]
+	// An empty host is resolved when connecting, e.g. from PGHOST
 	if host2.Name != "name2" ||
-		host2.Host != "localhost" ||
+		host2.Host != "" ||
 		host2.Port != 3306 ||
 		host2.User != "gggg" ||
 		host2.Password != "doesntmatter" {
@@ -53,7 +60,6 @@ hosts:
     user: gggg
     password: doesntmatter
   name2:
-    host: localhost
     port: 3306
     user: gggg
     password: doesntmatter
@@ -62,3 +68,241 @@ hosts:
 		t.Fatalf("Marshaling failed!\n output yaml:`\n%s`\ninstead of:`\n%s`", outputYaml, expectedYaml)
 	}
 }
+
+func TestConnectDefaults(t *testing.T) {
+	tests := []struct {
+		host         Host
+		env          map[string]string
+		expectedHost string
+		expectedPort int
+	}{
+		{Host{DbType: "mysql"}, nil, "localhost", 3306},
+		{Host{DbType: "postgresql", Port: 6543}, nil, "localhost", 6543},
+		{Host{DbType: "postgresql"}, map[string]string{"PGHOST": "db.internal"}, "db.internal", 5432},
+		{Host{DbType: "sqlite", File: "./fixtures.db"}, nil, "", 0},
+	}
+	for _, test := range tests {
+		info, err := database.LookupDriver(test.host.DbType)
+		if err != nil {
+			t.Fatalf("Failed to look up %s: %s", test.host.DbType, err)
+		}
+		env := credentials.Environment{
+			Getenv:  func(key string) string { return test.env[key] },
+			HomeDir: t.TempDir(),
+		}
+		host, _, err := test.host.withCredentials(env)
+		if err != nil {
+			t.Fatalf("Failed to resolve credentials of %#v: %s", test.host, err)
+		}
+		host = host.withDefaults(info)
+		if host.Host != test.expectedHost || host.Port != test.expectedPort {
+			t.Fatalf("Expected %s:%d for %#v, got %s:%d", test.expectedHost, test.expectedPort, test.host, host.Host, host.Port)
+		}
+	}
+}
+
+func TestUnmarshalSqliteHost(t *testing.T) {
+	yaml := `
+hosts:
+  fixtures:
+    dbType: sqlite
+    file: ./fixtures.db
+`[1:]
+
+	hosts, err := unmarshalHosts([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Unexpected error while ummarshaling %s", err)
+	}
+	if len(hosts) != 1 {
+		t.Fatalf("Wrong number of hosts: %d", len(hosts))
+	}
+	host := hosts[0]
+	if host.Name != "fixtures" ||
+		host.DbType != "sqlite" ||
+		host.Host != "" ||
+		host.File != "./fixtures.db" {
+		t.Fatalf("Incorrect host parsed `%#v`", host)
+	}
+
+	outputYaml := marshalHosts(hosts)
+	if outputYaml != yaml {
+		t.Fatalf("Marshaling failed!\n output yaml:`\n%s`\ninstead of:`\n%s`", outputYaml, yaml)
+	}
+}
+
+func TestUnmarshalSSHHost(t *testing.T) {
+	yaml := `
+hosts:
+  behindBastion:
+    dbType: postgresql
+    host: db.internal
+    port: 5432
+    user: admin
+    ssh:
+      host: bastion.example.com
+      port: 2222
+      user: jump
+      keyFile: ~/.ssh/id_ed25519
+`[1:]
+
+	hosts, err := unmarshalHosts([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Unexpected error while ummarshaling %s", err)
+	}
+	if len(hosts) != 1 || hosts[0].SSH == nil {
+		t.Fatalf("Incorrect hosts parsed `%#v`", hosts)
+	}
+	tunnel := hosts[0].SSH
+	if tunnel.Host != "bastion.example.com" ||
+		tunnel.Port != 2222 ||
+		tunnel.User != "jump" ||
+		tunnel.KeyFile != "~/.ssh/id_ed25519" {
+		t.Fatalf("Incorrect tunnel parsed `%#v`", tunnel)
+	}
+	if address := tunnel.Address(); address != "jump@bastion.example.com:2222" {
+		t.Fatalf("Incorrect address `%s`", address)
+	}
+
+	outputYaml := marshalHosts(hosts)
+	if outputYaml != yaml {
+		t.Fatalf("Marshaling failed!\n output yaml:`\n%s`\ninstead of:`\n%s`", outputYaml, yaml)
+	}
+}
+
+func TestParseSSHAddress(t *testing.T) {
+	tests := []struct {
+		address  string
+		expected SSHTunnel
+	}{
+		{"bastion", SSHTunnel{Host: "bastion"}},
+		{"jump@bastion", SSHTunnel{Host: "bastion", User: "jump"}},
+		{"jump@bastion:2222", SSHTunnel{Host: "bastion", User: "jump", Port: 2222}},
+		{"bastion:22", SSHTunnel{Host: "bastion", Port: 22}},
+	}
+	for _, test := range tests {
+		tunnel, err := parseSSHAddress(test.address)
+		if err != nil {
+			t.Fatalf("Failed to parse `%s`: %s", test.address, err)
+		}
+		if *tunnel != test.expected {
+			t.Fatalf("Incorrect tunnel for `%s`: %#v", test.address, tunnel)
+		}
+		if tunnel.Address() != test.address {
+			t.Fatalf("Address `%s` does not round trip: %s", test.address, tunnel.Address())
+		}
+	}
+
+	for _, address := range []string{"", "jump@", "bastion:port", "bastion:0"} {
+		if _, err := parseSSHAddress(address); err == nil {
+			t.Fatalf("Expected an error for `%s`", address)
+		}
+	}
+}
+
+func TestUnmarshalTLSHost(t *testing.T) {
+	yaml := `
+hosts:
+  production:
+    dbType: postgresql
+    host: db.internal
+    port: 5432
+    tls:
+      mode: verify-full
+      ca: /etc/ssl/private-ca.pem
+      serverName: db.example.com
+`[1:]
+
+	hosts, err := unmarshalHosts([]byte(yaml))
+	if err != nil {
+		t.Fatalf("Unexpected error while ummarshaling %s", err)
+	}
+	if len(hosts) != 1 || hosts[0].TLS == nil {
+		t.Fatalf("Incorrect hosts parsed `%#v`", hosts)
+	}
+	tls, err := hosts[0].TLS.config()
+	if err != nil {
+		t.Fatalf("Invalid TLS config: %s", err)
+	}
+	if tls.Mode != database.TLSVerifyFull || tls.CAFile != "/etc/ssl/private-ca.pem" || tls.ServerName != "db.example.com" {
+		t.Fatalf("Incorrect TLS parsed `%#v`", tls)
+	}
+
+	outputYaml := marshalHosts(hosts)
+	if outputYaml != yaml {
+		t.Fatalf("Marshaling failed!\n output yaml:`\n%s`\ninstead of:`\n%s`", outputYaml, yaml)
+	}
+
+	hosts[0].TLS.Mode = "verify"
+	if _, err := hosts[0].TLS.config(); err == nil {
+		t.Fatalf("Expected an error for an unknown mode")
+	}
+}
+
+func TestLoadDefaultHost(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	hosts, err := LoadHosts()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(hosts) != 1 || hosts[0].DbType != "postgresql" || hosts[0].Host != "localhost" || hosts[0].Port != 5432 {
+		t.Fatalf("Expected a local postgres host without a config, got %#v", hosts)
+	}
+}
+
+func TestSaveHostsPermissions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "lazysql")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	path := filepath.Join(dir, "hosts.yaml")
+	// Files written by older versions were readable by everyone
+	if err := os.WriteFile(path, []byte("hosts: {}\n"), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if err := SaveHosts([]*Host{{Name: "local", Password: "secret"}}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("hosts.yaml should only be readable by the user, got %s", info.Mode().Perm())
+	}
+	info, err = os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if info.Mode().Perm() != 0o700 {
+		t.Fatalf("Existing config dir should become accessible only by the user, got %s", info.Mode().Perm())
+	}
+
+	os.RemoveAll(filepath.Join(home, ".config"))
+	if err := SaveHosts([]*Host{{Name: "local"}}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	info, err = os.Stat(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if info.Mode().Perm() != 0o700 {
+		t.Fatalf("Config dir should only be accessible by the user, got %s", info.Mode().Perm())
+	}
+}
+
+func TestQueryTimeout(t *testing.T) {
+	timeout, err := Host{QueryTimeout: "30s"}.queryTimeout()
+	if err != nil || timeout != 30*time.Second {
+		t.Fatalf("Wrong timeout %s, %v", timeout, err)
+	}
+	timeout, err = Host{}.queryTimeout()
+	if err != nil || timeout != 0 {
+		t.Fatalf("No timeout should be 0, got %s, %v", timeout, err)
+	}
+	if _, err := (Host{QueryTimeout: "30"}).queryTimeout(); err == nil {
+		t.Fatalf("Expected an error for a timeout without unit")
+	}
+}
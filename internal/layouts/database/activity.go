@@ -0,0 +1,219 @@
+package _databaseLayout
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/gui"
+	"github.com/Kavantix/lazysql/internal/popup"
+	"github.com/mattn/go-runewidth"
+)
+
+// activityRefreshInterval is how often the sessions are listed while the activity is shown
+const activityRefreshInterval = 2 * time.Second
+
+// ActivityView lists the sessions connected to the server and can cancel or terminate them
+// The list is refreshed periodically while it is shown
+type ActivityView struct {
+	g            *gocui.Gui
+	context      *databaseContext
+	pane         *gui.Pane[*paneableSession]
+	monitor      database.ActivityMonitor
+	previousView string
+	// stopRefresh stops refreshing the sessions, nil when they are not refreshed
+	stopRefresh chan struct{}
+}
+
+type paneableSession struct {
+	database.Session
+}
+
+func (s *paneableSession) String() string {
+	duration := ""
+	if s.HasDuration {
+		duration = database.FormatDuration(s.Duration)
+	}
+	state := s.State
+	if state == "active" || state == "Query" {
+		state = yellow(fitWidth(state, 10))
+	} else {
+		state = fitWidth(state, 10)
+	}
+	return strings.Join([]string{
+		fitWidth(fmt.Sprint(s.ID), 8),
+		fitWidth(s.User, 12),
+		fitWidth(s.Database, 12),
+		state,
+		fitWidth(duration, 9),
+		grey(fitWidth(s.WaitEvent, 24)),
+		strings.Join(strings.Fields(s.Query), " "),
+	}, " ")
+}
+
+func (s *paneableSession) EqualsPaneable(other gui.Paneable) bool {
+	otherSession, ok := other.(*paneableSession)
+	return ok && s.ID == otherSession.ID
+}
+
+// fitWidth pads or truncates text to width cells
+func fitWidth(text string, width int) string {
+	return runewidth.FillRight(runewidth.Truncate(text, width, "…"), width)
+}
+
+func NewActivityView(g *gocui.Gui, context *databaseContext, monitor database.ActivityMonitor) *ActivityView {
+	a := &ActivityView{
+		g:       g,
+		context: context,
+		pane:    gui.NewPane[*paneableSession](g, "Activity"),
+		monitor: monitor,
+	}
+	a.pane.View.Visible = false
+	g.SetViewOnBottom(a.pane.Name)
+	checkErr(a.pane.SetKeybinding('q', a.hide))
+	checkErr(a.pane.SetKeybinding('r', func() { go a.refresh() }))
+	checkErr(a.pane.SetKeybinding('x', a.confirmStop(false)))
+	checkErr(a.pane.SetKeybinding('X', a.confirmStop(true)))
+	return a
+}
+
+// Show lists the sessions and keeps refreshing them until the view is closed
+func (a *ActivityView) Show() {
+	a.pane.View.Title = "Activity"
+	a.pane.View.Subtitle = "x: cancel, X: terminate, r: refresh, q: close"
+	if current := a.g.CurrentView(); current != nil && current.Name() != a.pane.Name {
+		a.previousView = current.Name()
+	}
+	a.pane.View.Visible = true
+	a.g.SetViewOnTop(a.pane.Name)
+	a.pane.Select()
+	a.startRefresh()
+}
+
+func (a *ActivityView) hide() {
+	a.close()
+	if a.previousView != "" {
+		a.g.SetCurrentView(a.previousView)
+	}
+}
+
+func (a *ActivityView) close() {
+	a.pane.View.Visible = false
+	a.g.SetViewOnBottom(a.pane.Name)
+	if a.stopRefresh != nil {
+		close(a.stopRefresh)
+		a.stopRefresh = nil
+	}
+}
+
+func (a *ActivityView) startRefresh() {
+	if a.stopRefresh != nil {
+		return
+	}
+	stop := make(chan struct{})
+	a.stopRefresh = stop
+	go func() {
+		ticker := time.NewTicker(activityRefreshInterval)
+		defer ticker.Stop()
+		for {
+			a.refresh()
+			select {
+			case <-ticker.C:
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// refresh lists the sessions, a failure is shown in the title instead of a popup
+// since it would be repeated on every refresh
+func (a *ActivityView) refresh() {
+	sessions, err := a.monitor.Sessions()
+	a.g.Update(func(g *gocui.Gui) error {
+		if err != nil {
+			a.pane.View.Title = fmt.Sprintf("Activity (refresh failed: %s)", err)
+			return nil
+		}
+		items := make([]*paneableSession, len(sessions))
+		for i, session := range sessions {
+			items[i] = &paneableSession{session}
+		}
+		a.pane.SetContent(items)
+		a.pane.View.Title = fmt.Sprintf("Activity (%d sessions at %s)", len(sessions), time.Now().Format("15:04:05"))
+		return nil
+	})
+}
+
+// confirmStop asks to cancel the statement of the session under the cursor,
+// or to terminate it when terminate is true
+func (a *ActivityView) confirmStop(terminate bool) func() {
+	return func() {
+		item, ok := a.pane.ItemUnderCursor()
+		if !ok {
+			return
+		}
+		session := item.Session
+		message := fmt.Sprintf("Cancel the statement of session %d of %s?", session.ID, session.User)
+		if terminate {
+			message = fmt.Sprintf("Terminate session %d of %s?", session.ID, session.User)
+		}
+		if session.Query != "" {
+			message += "\n\n" + firstLine(database.Query(session.Query))
+		}
+		a.context.Confirm(message, func() error {
+			go a.stop(session, terminate)
+			return nil
+		})
+	}
+}
+
+func (a *ActivityView) stop(session database.Session, terminate bool) {
+	if terminate {
+		if a.context.HandleError(a.monitor.TerminateSession(session.ID)) {
+			return
+		}
+		a.context.Log(fmt.Sprintf("Terminated session %d", session.ID))
+	} else {
+		if a.context.HandleError(a.monitor.CancelSession(session.ID)) {
+			return
+		}
+		a.context.Log(fmt.Sprintf("Cancelled the statement of session %d", session.ID))
+	}
+	a.refresh()
+}
+
+// Position places the sessions over the results when they are shown
+// and closes them when another view was selected, the confirmation popups leave them open
+func (a *ActivityView) Position(left, top, right, bottom int) {
+	if !a.pane.View.Visible {
+		return
+	}
+	if current := a.g.CurrentView(); current != nil && current.Name() != a.pane.Name && current.Name() != popup.ViewName {
+		a.close()
+		return
+	}
+	a.pane.Position(left, top, right, bottom)
+}
+
+func (a *ActivityView) Paint() {
+	if !a.pane.View.Visible {
+		return
+	}
+	a.pane.Paint()
+}
+
+// showActivity opens the sessions connected to the server
+func (c *databaseContext) showActivity() {
+	if c.activityView == nil {
+		monitor, ok := c.db.(database.ActivityMonitor)
+		if !ok {
+			c.ShowInfo("The activity monitor is only supported for Postgres and MySQL")
+			return
+		}
+		c.activityView = NewActivityView(c.Gui(), c, monitor)
+	}
+	c.activityView.Show()
+}
@@ -0,0 +1,156 @@
+package _databaseLayout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/highlighting"
+	"github.com/alecthomas/chroma/quick"
+	"github.com/atotto/clipboard"
+)
+
+// DdlView shows the DDL of a table in a scrollable popup
+type DdlView struct {
+	name         string
+	g            *gocui.Gui
+	view         *gocui.View
+	ddl          string
+	lexer        string
+	dirty        bool
+	previousView string
+	onEdit       func(ddl string)
+}
+
+func NewDdlView(g *gocui.Gui, onEdit func(ddl string)) *DdlView {
+	d := &DdlView{
+		name:   "DDL",
+		g:      g,
+		onEdit: onEdit,
+	}
+	d.view, _ = g.SetView(d.name, 0, 0, 1, 1, 0)
+	d.view.Visible = false
+	g.SetViewOnBottom(d.name)
+
+	g.SetKeybinding(d.name, gocui.KeyEsc, gocui.ModNone, d.hide)
+	g.SetKeybinding(d.name, 'q', gocui.ModNone, d.hide)
+	g.SetKeybinding(d.name, 'j', gocui.ModNone, d.scroll(1))
+	g.SetKeybinding(d.name, 'k', gocui.ModNone, d.scroll(-1))
+	g.SetKeybinding(d.name, gocui.KeyArrowDown, gocui.ModNone, d.scroll(1))
+	g.SetKeybinding(d.name, gocui.KeyArrowUp, gocui.ModNone, d.scroll(-1))
+	g.SetKeybinding(d.name, gocui.MouseWheelDown, gocui.ModNone, d.scroll(1))
+	g.SetKeybinding(d.name, gocui.MouseWheelUp, gocui.ModNone, d.scroll(-1))
+	g.SetKeybinding(d.name, gocui.KeyPgdn, gocui.ModNone, d.scrollPage(1))
+	g.SetKeybinding(d.name, gocui.KeyPgup, gocui.ModNone, d.scrollPage(-1))
+	g.SetKeybinding(d.name, 'y', gocui.ModNone, d.copy)
+	g.SetKeybinding(d.name, 'e', gocui.ModNone, d.edit)
+	return d
+}
+
+// Show shows ddl as the DDL of table until the popup is closed
+func (d *DdlView) Show(table database.Table, ddl string, dialect database.Dialect) {
+	d.ddl = ddl
+	d.lexer = lexerName(dialect)
+	d.dirty = true
+	d.view.Title = fmt.Sprintf("DDL of %s", strings.TrimSpace(table.DisplayString()))
+	d.view.Subtitle = "y: copy, e: edit, esc: close"
+	d.view.SetOrigin(0, 0)
+	if current := d.g.CurrentView(); current != nil && current.Name() != d.name {
+		d.previousView = current.Name()
+	}
+	d.view.Visible = true
+	d.g.SetViewOnTop(d.name)
+	d.g.SetCurrentView(d.name)
+}
+
+func (d *DdlView) hide(g *gocui.Gui, v *gocui.View) error {
+	d.view.Visible = false
+	g.SetViewOnBottom(d.name)
+	if d.previousView != "" {
+		g.SetCurrentView(d.previousView)
+	}
+	return nil
+}
+
+func (d *DdlView) copy(g *gocui.Gui, v *gocui.View) error {
+	if err := clipboard.WriteAll(d.ddl); err != nil {
+		d.view.Subtitle = fmt.Sprintf("copy failed: %s", err)
+		return nil
+	}
+	d.view.Subtitle = "copied to clipboard"
+	return nil
+}
+
+func (d *DdlView) edit(g *gocui.Gui, v *gocui.View) error {
+	d.hide(g, v)
+	d.onEdit(d.ddl)
+	return nil
+}
+
+func (d *DdlView) scroll(amount int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		d.scrollBy(amount)
+		return nil
+	}
+}
+
+func (d *DdlView) scrollPage(direction int) func(g *gocui.Gui, v *gocui.View) error {
+	return func(g *gocui.Gui, v *gocui.View) error {
+		_, sy := d.view.Size()
+		d.scrollBy(direction * sy / 2)
+		return nil
+	}
+}
+
+func (d *DdlView) scrollBy(amount int) {
+	ox, oy := d.view.Origin()
+	_, sy := d.view.Size()
+	lines := strings.Count(d.ddl, "\n") + 1
+	oy = min(oy+amount, lines-sy)
+	d.view.SetOrigin(ox, max(oy, 0))
+}
+
+// Position places the popup over the center of the screen when it is shown
+// and closes it when another view was selected
+func (d *DdlView) Position(maxX, maxY int) {
+	if !d.view.Visible {
+		return
+	}
+	if current := d.g.CurrentView(); current != nil && current.Name() != d.name {
+		d.view.Visible = false
+		d.g.SetViewOnBottom(d.name)
+		return
+	}
+	d.g.SetView(d.name, maxX/8, maxY/8, maxX*7/8, maxY*7/8, 0)
+}
+
+func (d *DdlView) Paint() {
+	if !d.view.Visible || !d.dirty {
+		return
+	}
+	d.dirty = false
+	d.view.Clear()
+	highlighting.CustomFormatter.SelectionStart = 0
+	highlighting.CustomFormatter.SelectionEnd = 0
+	quick.Highlight(d.view, d.ddl, d.lexer, highlighting.CustomFormatter.Name(), "monokai")
+}
+
+func (c *databaseContext) showDDL() {
+	item, ok := c.tablesPane.ItemUnderCursor()
+	if !ok {
+		return
+	}
+	table := item.Table
+	c.Log(fmt.Sprintf("Fetching DDL of %s", table.DisplayString()))
+	go func() {
+		ddl, err := c.db.DDL(table)
+		if c.HandleError(err) {
+			return
+		}
+		c.Gui().UpdateAsync(func(g *gocui.Gui) error {
+			c.ddlView.Show(table, ddl, c.db.Dialect())
+			return nil
+		})
+	}()
+}
@@ -201,13 +201,41 @@ func (q *QueryEditor) Paint() {
 		highlighting.CustomFormatter.SelectionEnd = q.selectionEnd
 	}
 	q.view.Title = fmt.Sprintf("%s (%s)", q.name, q.ModeName())
+	if open, statements := q.context.Transaction(); open {
+		q.view.Title += " " + transactionStatus(statements)
+		q.view.FrameColor = gocui.ColorYellow + 8
+	} else {
+		q.view.FrameColor = gocui.ColorDefault
+	}
 
 	ox, oy := q.view.Origin()
 	q.view.Clear()
 	q.view.SetOrigin(ox, oy)
 
 	if q.query != "" {
-		quick.Highlight(q.view, q.query, "mysql", highlighting.CustomFormatter.Name(), "monokai")
+		quick.Highlight(q.view, q.query, lexerName(q.context.Dialect()), highlighting.CustomFormatter.Name(), "monokai")
+	}
+}
+
+// moveToStatement moves the cursor to the start of statement
+// when the query still contains it at the same position
+func (q *QueryEditor) moveToStatement(statement database.Statement) {
+	if statement.End > len(q.query) || database.Query(q.query[statement.Start:statement.End]) != statement.Query {
+		return
+	}
+	q.mode = ModeNormal
+	q.cursor = statement.Start
+}
+
+// lexerName returns the name of the chroma lexer for dialect
+func lexerName(dialect database.Dialect) string {
+	switch dialect {
+	case database.DialectPostgres:
+		return "postgresql"
+	case database.DialectMysql:
+		return "mysql"
+	default:
+		return "sql"
 	}
 }
 
@@ -306,7 +334,22 @@ func (q *QueryEditor) EditNormal(v *gocui.View, key gocui.Key, ch rune, mod gocu
 			q.context.SelectTablesPane()
 		}
 	case key == gocui.KeyEnter:
-		q.context.ExecuteQuery(database.Query(q.query))
+		statements := database.SplitStatements(q.query, q.context.Dialect())
+		if statement, ok := database.StatementAt(statements, q.cursor); ok {
+			q.context.ExecuteQuery(statement.Query)
+		}
+	case ch == '!':
+		statements := database.SplitStatements(q.query, q.context.Dialect())
+		if statement, ok := database.StatementAt(statements, q.cursor); ok {
+			q.context.ExecuteQueryWithoutTimeout(statement.Query)
+		}
+	case ch == 'x', ch == 'X':
+		statements := database.SplitStatements(q.query, q.context.Dialect())
+		if statement, ok := database.StatementAt(statements, q.cursor); ok {
+			q.context.ExplainQuery(statement.Query, ch == 'X')
+		}
+	case ch == 'R':
+		q.context.ExecuteScript(database.SplitStatements(q.query, q.context.Dialect()))
 	case ch == 'i':
 		q.mode = ModeInsert
 	case ch == 'V':
@@ -20,10 +20,27 @@ type HistoryPane struct {
 type paneableQuery struct {
 	id    int
 	value database.Query
+	// write is true when the query changed data or schema
+	write bool
+	// params are the values the parameters of the query were bound to
+	params []paramValue
+	// summary describes the outcome of the last execution, like the amount of rows and the duration
+	summary string
 }
 
 func (p *paneableQuery) String() string {
-	return fmt.Sprintf("%d: %s", p.id, strings.ReplaceAll(string(p.value), "\n", " "))
+	marker := ""
+	if p.write {
+		marker = "[write] "
+	}
+	query := strings.ReplaceAll(string(p.value), "\n", " ")
+	if len(p.params) > 0 {
+		query = fmt.Sprintf("%s (%s)", query, formatParams(p.params))
+	}
+	if p.summary != "" {
+		query = fmt.Sprintf("%s [%s]", query, p.summary)
+	}
+	return fmt.Sprintf("%d: %s%s", p.id, marker, query)
 }
 
 func (p *paneableQuery) EqualsPaneable(other gui.Paneable) bool {
@@ -51,12 +68,27 @@ func (h *HistoryPane) Paint() {
 	h.pane.Paint()
 }
 
-func (h *HistoryPane) AddQuery(newQuery database.Query) {
-	if len(h.queries) > 0 && h.queries[0].value == newQuery {
+// AddQuery adds an executed query to the top of the history,
+// summary describes its outcome like the amount of rows and how long it took
+func (h *HistoryPane) AddQuery(newQuery database.Query, write bool, params []paramValue, summary string) {
+	if len(h.queries) > 0 && h.queries[0].value == newQuery && !write {
+		h.queries[0].params = params
+		h.queries[0].summary = summary
+		h.pane.SetContent(h.queries)
 		return
 	}
 	h.lastId += 1
-	h.queries = append([]*paneableQuery{{h.lastId, newQuery}}, h.queries...)
+	h.queries = append([]*paneableQuery{{h.lastId, newQuery, write, params, summary}}, h.queries...)
 	h.pane.SetContent(h.queries)
 	h.pane.Selected = h.queries[0]
 }
+
+// LastParams returns the parameter values query was last executed with, nil when it has none
+func (h *HistoryPane) LastParams(query database.Query) []paramValue {
+	for _, item := range h.queries {
+		if item.value == query && len(item.params) > 0 {
+			return item.params
+		}
+	}
+	return nil
+}
@@ -18,12 +18,22 @@ type databaseContext struct {
 	databases        []database.Database
 	selectedDatabase database.Database
 	selectedTable    database.Table
+	tables           []database.Table
+	hiddenKinds      map[database.ObjectKind]bool
+	// pendingTable is the name of the table to select once the tables are loaded
+	pendingTable string
 
 	tablesPane               *gui.Pane[PaneableTable]
 	databasesPane, queryPane *gui.Pane[gui.PaneableString]
 	resultsPane              *ResultsPane
 	historyPane              *HistoryPane
 	queryEditor              *QueryEditor
+	ddlView                  *DdlView
+	planView                 *PlanView
+	paramsView               *ParamsView
+	// notificationsView and activityView are created when they are first opened
+	notificationsView *NotificationsView
+	activityView      *ActivityView
 }
 
 type baseContext interface {
@@ -38,7 +48,7 @@ type PaneableTable struct {
 }
 
 func (t PaneableTable) String() string {
-	return t.DisplayString()
+	return string(t.Kind().Marker()) + " " + t.DisplayString()
 }
 
 func (t PaneableTable) EqualsPaneable(other gui.Paneable) bool {
@@ -46,29 +56,37 @@ func (t PaneableTable) EqualsPaneable(other gui.Paneable) bool {
 	return ok && t.EqualsTable(otherTable.Table)
 }
 
-func Show(baseContext baseContext, db database.Driver, databases []database.Database) {
+func Show(baseContext baseContext, db database.Driver, databases []database.Database, selection gui.Selection) {
 	context := &databaseContext{
 		baseContext: baseContext,
 		db:          db,
 		databases:   databases,
+		hiddenKinds: map[database.ObjectKind]bool{},
 	}
 	g := context.Gui()
 
 	g.SetManagerFunc(context.layout)
 	checkErr(g.SetKeybinding("", gocui.KeyCtrlC, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		context.Log("Disconnecting")
-		err := db.Close()
-		if err != nil {
-			context.ShowError(err.Error())
-			return nil
-		}
-		context.Log("Disconnected")
-		context.ShowConfigLayout()
-		return nil
+		return context.confirmIfTransactionOpen("disconnect", context.disconnect)
 	}))
 
 	checkErr(g.SetKeybinding("", 'q', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
-		return gocui.ErrQuit
+		return context.confirmIfTransactionOpen("quit", func() error {
+			return gocui.ErrQuit
+		})
+	}))
+	checkErr(g.SetKeybinding("", 'T', gocui.ModNone, context.toggleTransaction))
+	checkErr(g.SetKeybinding("", 'N', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		context.showNotifications()
+		return nil
+	}))
+	checkErr(g.SetKeybinding("", 'A', gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		context.showActivity()
+		return nil
+	}))
+	checkErr(g.SetKeybinding("", gocui.KeyCtrlX, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		context.CancelQuery()
+		return nil
 	}))
 	checkErr(g.SetKeybinding("", 'h', gocui.ModNone, context.currentViewUp))
 	checkErr(g.SetKeybinding("", gocui.KeyArrowLeft, gocui.ModNone, context.currentViewUp))
@@ -97,38 +115,382 @@ func Show(baseContext baseContext, db database.Driver, databases []database.Data
 
 	context.InitPopupView()
 	context.resultsPane = NewResultsPane(g)
+	context.resultsPane.OnLoadMore(context.loadMoreRows)
+	context.resultsPane.OnProgress(func() database.Progress {
+		return context.db.Progress()
+	})
 
 	context.queryEditor, err = NewQueryEditor(g, context)
 	checkErr(err)
 
 	context.tablesPane = gui.NewPane[PaneableTable](g, "Tables")
 	context.tablesPane.OnSelectItem(context.onSelectTable)
+	checkErr(context.tablesPane.SetKeybinding('s', context.showStructure))
+	checkErr(context.tablesPane.SetKeybinding('K', context.chooseKinds))
+	checkErr(context.tablesPane.SetKeybinding('d', context.showDDL))
+
+	context.ddlView = NewDdlView(g, func(ddl string) {
+		context.queryEditor.query = ddl
+		context.queryEditor.cursor = 0
+		context.queryEditor.Select()
+	})
+	context.planView = NewPlanView(g)
+	context.paramsView = NewParamsView(g)
+
+	if selection != (gui.Selection{}) {
+		context.preselect(selection)
+	}
+}
+
+// preselect selects the database and table of selection as if the user chose them
+// The database can be left out when there is only one
+func (context *databaseContext) preselect(selection gui.Selection) {
+	if selection.Database == "" && len(context.databases) == 1 {
+		selection.Database = context.databases[0]
+	}
+	if selection.Database == "" {
+		context.ShowError("Select a database to select a table in")
+		return
+	}
+	for i, db := range context.databases {
+		if db != selection.Database {
+			continue
+		}
+		context.databasesPane.SetCursor(i)
+		context.databasesPane.Selected = gui.PaneableString(db)
+		context.pendingTable = selection.Table
+		context.changeDatabase(context.Gui(), db)
+		return
+	}
+	context.ShowError(fmt.Sprintf("Database %s does not exist", selection.Database))
+}
+
+// preselectTable selects the table named name in the tables pane
+func (context *databaseContext) preselectTable(name string) {
+	index := 0
+	for _, table := range context.tables {
+		if context.hiddenKinds[table.Kind()] {
+			continue
+		}
+		if qualified := qualifiedName(table); qualified == name || strings.HasSuffix(qualified, "."+name) {
+			context.tablesPane.SetCursor(index)
+			context.tablesPane.Selected = PaneableTable{table}
+			context.changeTable(table)
+			return
+		}
+		index += 1
+	}
+	context.ShowError(fmt.Sprintf("Table %s does not exist in %s", name, context.selectedDatabase))
+}
+
+// qualifiedName returns the name of table as schema.table when it is shown with its schema
+func qualifiedName(table database.Table) string {
+	parts := strings.Split(table.DisplayString(), "|")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return strings.Join(parts, ".")
 }
 
 func (c *databaseContext) ExecuteQuery(query database.Query) {
-	c.Log("Executing query")
-	c.executeQuery(query, true)
+	c.withParameters(query, func(params []paramValue) {
+		c.Log("Executing query")
+		c.executeQuery(query, params, true)
+	})
+}
+
+func (c *databaseContext) ExecuteQueryWithoutTimeout(query database.Query) {
+	c.withParameters(query, func(params []paramValue) {
+		c.Log("Executing query without timeout")
+		c.db.WithoutTimeout()
+		c.executeQuery(query, params, true)
+	})
+}
+
+func (c *databaseContext) Dialect() database.Dialect {
+	return c.db.Dialect()
+}
+
+func (c *databaseContext) executeQuery(query database.Query, params []paramValue, saveHistory bool) {
+	c.resultsPane.Clear()
+	go func() {
+		c.reportOutcome(c.runQuery(query, params, saveHistory))
+	}()
 }
 
-func (c *databaseContext) executeQuery(query database.Query, saveHistory bool) {
+func (c *databaseContext) ExecuteScript(statements []database.Statement) {
+	if len(statements) == 0 {
+		return
+	}
+	for _, statement := range statements {
+		names, err := statement.Query.Parameters(c.db.Dialect())
+		if c.HandleError(err) {
+			return
+		}
+		if len(names) > 0 {
+			c.ShowError(fmt.Sprintf("Scripts cannot have parameters, execute this statement on its own:\n\n%s", firstLine(statement.Query)))
+			return
+		}
+	}
+	c.Log(fmt.Sprintf("Executing script of %d statements", len(statements)))
+	c.resultsPane.Clear()
 	go func() {
-		c.resultsPane.View.HasLoader = true
-		c.resultsPane.Clear()
-		result, err := c.db.Query(query)
+		for i, statement := range statements {
+			_, err := c.runQuery(statement.Query, nil, true)
+			if err != nil {
+				c.Log(fmt.Sprintf("Statement %d of %d failed", i+1, len(statements)))
+				c.ShowError(fmt.Sprintf("Statement %d of %d failed: %s\n\n%s", i+1, len(statements), err, firstLine(statement.Query)))
+				c.Gui().UpdateAsync(func(g *gocui.Gui) error {
+					c.queryEditor.moveToStatement(statement)
+					return nil
+				})
+				return
+			}
+		}
+		c.Log(fmt.Sprintf("Executed %d statements", len(statements)))
+		c.ShowSuccess(fmt.Sprintf("Executed %d statements", len(statements)))
+	}()
+}
+
+// runQuery executes query and adds its results to the results pane, it blocks until the query is done
+// Returns the message to report on success
+func (c *databaseContext) runQuery(query database.Query, params []paramValue, saveHistory bool) (string, error) {
+	switch query.TransactionControl() {
+	case database.TxBegin:
+		return c.runBegin()
+	case database.TxCommit:
+		return c.runCommit()
+	case database.TxRollback:
+		return c.runRollback()
+	}
+	c.resultsPane.View.HasLoader = true
+	defer func() {
 		c.resultsPane.View.HasLoader = false
-		if err != nil {
-			c.ShowError(err.Error())
-		} else {
-			if saveHistory {
-				c.historyPane.AddQuery(query)
+	}()
+	bound, args, err := bindParams(query, c.db.Dialect(), params)
+	if err != nil {
+		return "", err
+	}
+	defer c.showMessages()
+	if !query.ReturnsRows() {
+		return c.runStatement(query, bound, args, params, saveHistory)
+	}
+	results, err := c.db.Query(bound, args...)
+	if err != nil {
+		return "", err
+	}
+	summary := resultsSummary(results)
+	if saveHistory {
+		c.historyPane.AddQuery(query, query.IsWrite(), params, summary)
+	}
+	if len(results) == 1 && len(results[0].Columns) == 0 {
+		c.Log("Statement returned no rows")
+		return "Statement returned no rows", nil
+	}
+	c.resultsPane.AddResults(firstLine(query), results)
+	if tab, ok := skippedResultSetsTab(query, c.db.Dialect(), results); ok {
+		c.resultsPane.AppendTabs(tab)
+	}
+	return "", nil
+}
+
+// skippedResultSetsTab returns a tab explaining that the result sets after the last result were skipped,
+// which happens when a query with several statements has a result that was not read completely
+func skippedResultSetsTab(query database.Query, dialect database.Dialect, results []*database.QueryResult) (Tab, bool) {
+	last := results[len(results)-1]
+	if !last.HasMore || len(database.SplitStatements(string(query), dialect)) < 2 {
+		return Tab{}, false
+	}
+	result := textResult("message")
+	result.Rows = append(result.Rows,
+		[]database.Cell{{Value: "Any result sets after the last result were skipped since it has more rows than fit on a page"}},
+		[]database.Cell{{Value: "Run the statements after it separately to see their results"}},
+	)
+	return Tab{Caption: "Skipped result sets", Result: result}, true
+}
+
+// resultsSummary describes the amount of rows in results and how long the query took
+func resultsSummary(results []*database.QueryResult) string {
+	rows := 0
+	hasMore := false
+	for _, result := range results {
+		rows += len(result.Rows)
+		hasMore = hasMore || result.HasMore
+	}
+	duration := database.FormatDuration(results[0].Duration)
+	if hasMore {
+		return fmt.Sprintf("%d rows loaded, more available, %s", rows, duration)
+	}
+	return fmt.Sprintf("%d rows, %s", rows, duration)
+}
+
+// runStatement executes a query that does not return rows, bound is query with args bound to its parameters
+func (c *databaseContext) runStatement(query, bound database.Query, args []any, params []paramValue, saveHistory bool) (string, error) {
+	result, err := c.db.Exec(bound, args...)
+	if err != nil {
+		return "", err
+	}
+	summary := fmt.Sprintf("%s, %s", result, database.FormatDuration(result.Duration))
+	if saveHistory {
+		c.historyPane.AddQuery(query, true, params, summary)
+	}
+	c.Log(fmt.Sprintf("[%s]: %s", query.Keyword(), summary))
+	return result.String(), nil
+}
+
+// reportOutcome shows err, or message when it is not empty
+func (c *databaseContext) reportOutcome(message string, err error) {
+	if c.HandleError(err) {
+		return
+	}
+	if message != "" {
+		c.ShowSuccess(message)
+	}
+}
+
+func firstLine(query database.Query) string {
+	line, _, found := strings.Cut(strings.TrimSpace(string(query)), "\n")
+	if found {
+		return line + " …"
+	}
+	return line
+}
+
+func (c *databaseContext) loadMoreRows(result *database.QueryResult) {
+	go func() {
+		rows, hasMore, truncated, err := c.db.FetchMore(result)
+		c.HandleError(err)
+		c.resultsPane.AppendRows(result, rows, hasMore, truncated)
+	}()
+}
+
+func (c *databaseContext) CancelQuery() {
+	go func() {
+		outcome, err := c.db.CancelQuery()
+		switch outcome {
+		case database.CancelNothingRunning:
+			c.ShowInfo("No query is running")
+		case database.CancelLocal:
+			c.Log("Cancelled query")
+		case database.CancelConfirmed:
+			c.Log("Cancelled query, confirmed by the server")
+		case database.CancelUnconfirmed:
+			message := "The server did not confirm cancelling the query, it could still be running"
+			if err != nil {
+				message += ": " + err.Error()
 			}
-			c.resultsPane.SetContent(result.Columns, result.Data)
+			c.ShowWarning(message)
 		}
 	}()
 }
 
-func (c *databaseContext) CancelQuery() bool {
-	return c.db.CancelQuery()
+func (c *databaseContext) Transaction() (open bool, statements int) {
+	return c.db.Transaction()
+}
+
+// Status implements gui.StatusContext.
+func (c *databaseContext) Status() string {
+	open, statements := c.db.Transaction()
+	if !open {
+		return ""
+	}
+	return transactionStatus(statements)
+}
+
+func transactionStatus(statements int) string {
+	if statements == 1 {
+		return "TX open (1 statement)"
+	}
+	return fmt.Sprintf("TX open (%d statements)", statements)
+}
+
+func (c *databaseContext) toggleTransaction(g *gocui.Gui, v *gocui.View) error {
+	if open, _ := c.db.Transaction(); !open {
+		c.begin()
+		return nil
+	}
+	c.ShowChoices("Transaction", c.Status(), []gui.Choice{
+		{Key: 'c', Label: "Commit", OnChoose: func() error {
+			c.commit()
+			return nil
+		}},
+		{Key: 'r', Label: "Rollback", OnChoose: func() error {
+			c.rollback()
+			return nil
+		}},
+	})
+	return nil
+}
+
+func (c *databaseContext) begin() {
+	go func() {
+		c.reportOutcome(c.runBegin())
+	}()
+}
+
+func (c *databaseContext) commit() {
+	go func() {
+		c.reportOutcome(c.runCommit())
+	}()
+}
+
+func (c *databaseContext) rollback() {
+	go func() {
+		c.reportOutcome(c.runRollback())
+	}()
+}
+
+func (c *databaseContext) runBegin() (string, error) {
+	if err := c.db.Begin(); err != nil {
+		return "", err
+	}
+	c.Log("Transaction started")
+	return "", nil
+}
+
+func (c *databaseContext) runCommit() (string, error) {
+	if err := c.db.Commit(); err != nil {
+		return "", err
+	}
+	c.Log("Transaction committed")
+	return "Transaction committed", nil
+}
+
+func (c *databaseContext) runRollback() (string, error) {
+	if err := c.db.Rollback(); err != nil {
+		return "", err
+	}
+	c.Log("Transaction rolled back")
+	return "Transaction rolled back", nil
+}
+
+// confirmIfTransactionOpen asks for confirmation before calling action
+// when leaving would roll back an open transaction
+func (c *databaseContext) confirmIfTransactionOpen(action string, onConfirm func() error) error {
+	open, _ := c.db.Transaction()
+	if !open {
+		return onConfirm()
+	}
+	c.Confirm(fmt.Sprintf("%s is still pending, %s and roll it back?", c.Status(), action), onConfirm)
+	return nil
+}
+
+func (c *databaseContext) disconnect() error {
+	c.Log("Disconnecting")
+	if c.notificationsView != nil {
+		c.notificationsView.Close()
+	}
+	if c.activityView != nil {
+		c.activityView.close()
+	}
+	err := c.db.Close()
+	if err != nil {
+		c.ShowError(err.Error())
+		return nil
+	}
+	c.Log("Disconnected")
+	c.ShowConfigLayout()
+	return nil
 }
 
 func (c *databaseContext) SelectTablesPane() {
@@ -151,6 +513,20 @@ func (context *databaseContext) layout(g *gocui.Gui) error {
 	context.resultsPane.Paint()
 	context.queryEditor.Position(maxX/3, 0, maxX-1, 6)
 	context.queryEditor.Paint()
+	context.planView.Position(maxX/3, 7, maxX-1, maxY-2)
+	context.planView.Paint()
+	if context.notificationsView != nil {
+		context.notificationsView.Position(maxX/3, 7, maxX-1, maxY-2)
+		context.notificationsView.Paint()
+	}
+	if context.activityView != nil {
+		context.activityView.Position(maxX/3, 7, maxX-1, maxY-2)
+		context.activityView.Paint()
+	}
+	context.ddlView.Position(maxX, maxY)
+	context.ddlView.Paint()
+	context.paramsView.Position(maxX, maxY)
+	context.paramsView.Paint()
 	if g.CurrentView().Name() == "Query" {
 		g.Cursor = true
 		lines := strings.Split(context.queryEditor.query, "\n")
@@ -200,17 +576,61 @@ func (context *databaseContext) changeDatabase(g *gocui.Gui, dbname database.Dat
 			g.UpdateAsync(func(g *gocui.Gui) error {
 				context.tablesPane.SetCursor(0)
 				context.tablesPane.Select()
-				tables := make([]PaneableTable, len(newTables))
-				for i, table := range newTables {
-					tables[i] = PaneableTable{table}
+				context.tables = newTables
+				context.showTables()
+				if table := context.pendingTable; table != "" {
+					context.pendingTable = ""
+					context.preselectTable(table)
 				}
-				context.tablesPane.SetContent(tables)
 				return nil
 			})
 		}()
 	}
 }
 
+// showTables shows the tables of the selected database whose kind is not hidden
+func (context *databaseContext) showTables() {
+	tables := []PaneableTable{}
+	for _, table := range context.tables {
+		if !context.hiddenKinds[table.Kind()] {
+			tables = append(tables, PaneableTable{table})
+		}
+	}
+	context.tablesPane.SetContent(tables)
+}
+
+// chooseKinds lets the user toggle which kinds of objects are shown in the tables pane
+func (context *databaseContext) chooseKinds() {
+	present := map[database.ObjectKind]bool{}
+	for _, table := range context.tables {
+		present[table.Kind()] = true
+	}
+	choices := []gui.Choice{}
+	for _, kind := range database.ObjectKinds {
+		if !present[kind] {
+			continue
+		}
+		checkbox := "[x]"
+		if context.hiddenKinds[kind] {
+			checkbox = "[ ]"
+		}
+		choices = append(choices, gui.Choice{
+			Key:   kind.Marker(),
+			Label: fmt.Sprintf("%s %s", checkbox, kind),
+			OnChoose: func() error {
+				context.hiddenKinds[kind] = !context.hiddenKinds[kind]
+				context.showTables()
+				context.tablesPane.Select()
+				return nil
+			},
+		})
+	}
+	if len(choices) == 0 {
+		return
+	}
+	context.ShowChoices("Kinds", "Toggle which kinds are shown", choices)
+}
+
 func (context *databaseContext) onSelectTable(table PaneableTable) {
 	context.changeTable(table.Table)
 }
@@ -222,9 +642,9 @@ func (context *databaseContext) changeTable(table database.Table) {
 	context.Log(fmt.Sprintf("Selecting data for table %s", table.DisplayString()))
 	if context.selectedTable != table {
 		context.selectedTable = table
-		query := context.db.QueryForTable(table, 9999)
+		query := context.db.QueryForTable(table)
 		context.queryEditor.query = string(query)
-		context.executeQuery(query, false)
+		context.executeQuery(query, nil, false)
 	}
 }
 
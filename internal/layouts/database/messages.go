@@ -0,0 +1,27 @@
+package _databaseLayout
+
+import (
+	"fmt"
+
+	"github.com/Kavantix/lazysql/internal/database"
+	. "github.com/Kavantix/lazysql/internal/layouts/database/results"
+)
+
+// showMessages logs the notices and warnings the server sent during the last query
+// and lists them in a tab next to its results
+func (c *databaseContext) showMessages() {
+	messages := c.db.Messages()
+	if len(messages) == 0 {
+		return
+	}
+	result := textResult("severity", "code", "message")
+	for _, message := range messages {
+		c.Log(message.String())
+		result.Rows = append(result.Rows, []database.Cell{
+			{Value: message.Severity},
+			{Value: message.Code},
+			{Value: message.Text},
+		})
+	}
+	c.resultsPane.AppendTabs(Tab{Caption: fmt.Sprintf("Messages (%d)", len(messages)), Result: result})
+}
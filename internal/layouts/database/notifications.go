@@ -0,0 +1,255 @@
+package _databaseLayout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/gui"
+	"github.com/Kavantix/lazysql/internal/popup"
+)
+
+// maxNotifications is the amount of received notifications that are kept
+const maxNotifications = 1000
+
+// NotificationsView subscribes to channels with LISTEN and shows the notifications sent to them
+// The subscription stays open while the view is closed so no notifications are missed
+type NotificationsView struct {
+	g             *gocui.Gui
+	context       *databaseContext
+	pane          *gui.Pane[*paneableNotification]
+	listener      database.Listener
+	notifications []*paneableNotification
+	previousView  string
+
+	// mutex guards subscription, which is opened in the background
+	mutex        sync.Mutex
+	subscription database.Subscription
+}
+
+type paneableNotification struct {
+	database.Notification
+}
+
+func (n *paneableNotification) String() string {
+	return fmt.Sprintf("%s %s %s %s",
+		grey(n.At.Format("15:04:05.000")),
+		yellow(n.Channel),
+		strings.ReplaceAll(n.Payload, "\n", " "),
+		grey(fmt.Sprintf("(pid %d)", n.PID)),
+	)
+}
+
+func (n *paneableNotification) EqualsPaneable(other gui.Paneable) bool {
+	return n == other
+}
+
+func NewNotificationsView(g *gocui.Gui, context *databaseContext, listener database.Listener) *NotificationsView {
+	n := &NotificationsView{
+		g:        g,
+		context:  context,
+		pane:     gui.NewPane[*paneableNotification](g, "Notifications"),
+		listener: listener,
+	}
+	n.pane.View.Visible = false
+	g.SetViewOnBottom(n.pane.Name)
+	checkErr(n.pane.SetKeybinding('q', n.hide))
+	checkErr(n.pane.SetKeybinding('a', n.promptListen))
+	checkErr(n.pane.SetKeybinding('u', n.promptUnlisten))
+	checkErr(n.pane.SetKeybinding('n', n.promptNotify))
+	checkErr(n.pane.SetKeybinding('x', n.clear))
+	return n
+}
+
+// Show shows the received notifications until the view is closed
+func (n *NotificationsView) Show() {
+	n.updateTitle()
+	n.pane.View.Subtitle = "a: listen, u: unlisten, n: notify, x: clear, q: close"
+	if current := n.g.CurrentView(); current != nil && current.Name() != n.pane.Name {
+		n.previousView = current.Name()
+	}
+	n.pane.View.Visible = true
+	n.g.SetViewOnTop(n.pane.Name)
+	n.pane.Select()
+}
+
+func (n *NotificationsView) hide() {
+	n.pane.View.Visible = false
+	n.g.SetViewOnBottom(n.pane.Name)
+	if n.previousView != "" {
+		n.g.SetCurrentView(n.previousView)
+	}
+}
+
+// channels returns the channels that are listened to
+func (n *NotificationsView) channels() []string {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.subscription == nil {
+		return nil
+	}
+	return n.subscription.Channels()
+}
+
+func (n *NotificationsView) updateTitle() {
+	channels := n.channels()
+	if len(channels) == 0 {
+		n.pane.View.Title = "Notifications (not listening)"
+		return
+	}
+	n.pane.View.Title = fmt.Sprintf("Notifications (listening to %s)", strings.Join(channels, ", "))
+}
+
+func (n *NotificationsView) promptListen() {
+	n.context.Prompt("Listen", "Channel to listen to", false, func(channel string) {
+		if channel == "" {
+			return
+		}
+		go n.listen(channel)
+	})
+}
+
+// listen opens the subscription on first use and starts listening to channel
+func (n *NotificationsView) listen(channel string) {
+	n.mutex.Lock()
+	if n.subscription == nil {
+		subscription, err := n.listener.Subscribe(n.receive, n.fail)
+		if err != nil {
+			n.mutex.Unlock()
+			n.context.HandleError(err)
+			return
+		}
+		n.subscription = subscription
+	}
+	err := n.subscription.Listen(channel)
+	n.mutex.Unlock()
+	if n.context.HandleError(err) {
+		return
+	}
+	n.context.Log(fmt.Sprintf("Listening to %s", channel))
+	n.g.Update(func(g *gocui.Gui) error {
+		n.updateTitle()
+		return nil
+	})
+}
+
+func (n *NotificationsView) promptUnlisten() {
+	if len(n.channels()) == 0 {
+		n.context.ShowInfo("Not listening to any channel, press a to listen to one")
+		return
+	}
+	n.context.Prompt("Unlisten", "Channel to stop listening to", false, func(channel string) {
+		if channel == "" {
+			return
+		}
+		go func() {
+			n.mutex.Lock()
+			err := errors.New("the connection for notifications was closed")
+			if n.subscription != nil {
+				err = n.subscription.Unlisten(channel)
+			}
+			n.mutex.Unlock()
+			if n.context.HandleError(err) {
+				return
+			}
+			n.context.Log(fmt.Sprintf("Stopped listening to %s", channel))
+			n.g.Update(func(g *gocui.Gui) error {
+				n.updateTitle()
+				return nil
+			})
+		}()
+	})
+}
+
+func (n *NotificationsView) promptNotify() {
+	n.context.Prompt("Notify", "Channel to notify", false, func(channel string) {
+		if channel == "" {
+			return
+		}
+		n.context.Prompt("Notify", fmt.Sprintf("Payload to send to %s", channel), false, func(payload string) {
+			go func() {
+				if n.context.HandleError(n.listener.Notify(channel, payload)) {
+					return
+				}
+				n.context.Log(fmt.Sprintf("Notified %s", channel))
+			}()
+		})
+	})
+}
+
+func (n *NotificationsView) clear() {
+	n.notifications = nil
+	n.pane.SetContent(n.notifications)
+}
+
+// receive adds notification at the top of the pane, it is called from the subscription
+func (n *NotificationsView) receive(notification database.Notification) {
+	n.g.Update(func(g *gocui.Gui) error {
+		n.notifications = append([]*paneableNotification{{notification}}, n.notifications...)
+		if len(n.notifications) > maxNotifications {
+			n.notifications = n.notifications[:maxNotifications]
+		}
+		n.pane.SetContent(n.notifications)
+		return nil
+	})
+}
+
+// fail reports that the subscription lost its connection, listening again opens a new one
+func (n *NotificationsView) fail(err error) {
+	go func() {
+		n.Close()
+		n.g.Update(func(g *gocui.Gui) error {
+			n.updateTitle()
+			return nil
+		})
+	}()
+	n.context.ShowError(fmt.Sprintf("Stopped listening for notifications: %s", err))
+}
+
+// Close closes the subscription
+func (n *NotificationsView) Close() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	if n.subscription != nil {
+		n.subscription.Close()
+		n.subscription = nil
+	}
+}
+
+// Position places the notifications over the results when they are shown
+// and closes them when another view was selected, the popups used to listen and notify leave them open
+func (n *NotificationsView) Position(left, top, right, bottom int) {
+	if !n.pane.View.Visible {
+		return
+	}
+	if current := n.g.CurrentView(); current != nil && current.Name() != n.pane.Name && current.Name() != popup.ViewName {
+		n.pane.View.Visible = false
+		n.g.SetViewOnBottom(n.pane.Name)
+		return
+	}
+	n.pane.Position(left, top, right, bottom)
+}
+
+func (n *NotificationsView) Paint() {
+	if !n.pane.View.Visible {
+		return
+	}
+	n.pane.Paint()
+}
+
+// showNotifications opens the notifications of the channels that are listened to,
+// only databases that support LISTEN and NOTIFY have them
+func (c *databaseContext) showNotifications() {
+	if c.notificationsView == nil {
+		listener, ok := c.db.(database.Listener)
+		if !ok {
+			c.ShowInfo("Notifications are only supported for Postgres")
+			return
+		}
+		c.notificationsView = NewNotificationsView(c.Gui(), c, listener)
+	}
+	c.notificationsView.Show()
+}
@@ -0,0 +1,225 @@
+package _databaseLayout
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/mattn/go-runewidth"
+)
+
+// paramValue is the value of a parameter of a query as it was typed
+type paramValue struct {
+	Name  string
+	Type  database.ParamType
+	Value string
+}
+
+func (p paramValue) String() string {
+	switch p.Type {
+	case database.ParamNull:
+		return p.Name + "=NULL"
+	case database.ParamText:
+		return fmt.Sprintf("%s='%s'", p.Name, p.Value)
+	default:
+		return p.Name + "=" + p.Value
+	}
+}
+
+// bindParams returns query with its parameters bound to the converted values of params
+func bindParams(query database.Query, dialect database.Dialect, params []paramValue) (database.Query, []any, error) {
+	if len(params) == 0 {
+		return query, nil, nil
+	}
+	values := map[string]any{}
+	for _, param := range params {
+		value, err := param.Type.Convert(param.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", param.Name, err)
+		}
+		values[param.Name] = value
+	}
+	return query.Bind(dialect, values)
+}
+
+// ParamsView is a form that asks for the values of the parameters of a query before it is executed
+type ParamsView struct {
+	name         string
+	g            *gocui.Gui
+	view         *gocui.View
+	params       []paramValue
+	selected     int
+	previousView string
+	onSubmit     func(params []paramValue)
+}
+
+func NewParamsView(g *gocui.Gui) *ParamsView {
+	p := &ParamsView{
+		name: "Parameters",
+		g:    g,
+	}
+	p.view, _ = g.SetView(p.name, 0, 0, 1, 1, 0)
+	p.view.Visible = false
+	p.view.Editor = p
+	g.SetViewOnBottom(p.name)
+
+	g.SetKeybinding(p.name, gocui.KeyEsc, gocui.ModNone, p.hide)
+	return p
+}
+
+// Show asks for the values of names, previous values are filled in for parameters that have one
+// onSubmit is called with the values once they are all valid
+func (p *ParamsView) Show(query database.Query, names []string, previous []paramValue, onSubmit func(params []paramValue)) {
+	p.params = make([]paramValue, len(names))
+	for i, name := range names {
+		p.params[i] = paramValue{Name: name, Type: database.ParamText}
+		for _, value := range previous {
+			if value.Name == name {
+				p.params[i] = value
+			}
+		}
+	}
+	p.selected = 0
+	p.onSubmit = onSubmit
+	p.view.Title = "Parameters of " + firstLine(query)
+	p.view.Subtitle = "tab: next, ←/→: type, enter: execute, esc: cancel"
+	if current := p.g.CurrentView(); current != nil && current.Name() != p.name {
+		p.previousView = current.Name()
+	}
+	p.view.Visible = true
+	p.view.Editable = true
+	p.g.SetViewOnTop(p.name)
+	p.g.SetCurrentView(p.name)
+}
+
+func (p *ParamsView) hide(g *gocui.Gui, v *gocui.View) error {
+	p.view.Visible = false
+	p.view.Editable = false
+	g.SetViewOnBottom(p.name)
+	if p.previousView != "" {
+		g.SetCurrentView(p.previousView)
+	}
+	return nil
+}
+
+// Edit implements gocui.Editor.
+func (p *ParamsView) Edit(v *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	param := &p.params[p.selected]
+	switch key {
+	case gocui.KeyEnter:
+		p.submit()
+	case gocui.KeyTab, gocui.KeyArrowDown:
+		p.selected = (p.selected + 1) % len(p.params)
+	case gocui.KeyBacktab, gocui.KeyArrowUp:
+		p.selected = (p.selected + len(p.params) - 1) % len(p.params)
+	case gocui.KeyArrowRight:
+		param.Type = cycleParamType(param.Type, 1)
+	case gocui.KeyArrowLeft:
+		param.Type = cycleParamType(param.Type, -1)
+	case gocui.KeyBackspace, gocui.KeyBackspace2:
+		if value := []rune(param.Value); len(value) > 0 {
+			param.Value = string(value[:len(value)-1])
+		}
+	case gocui.KeySpace:
+		param.Value += " "
+	case 0:
+		if ch != 0 {
+			param.Value += string(ch)
+		}
+	}
+}
+
+func cycleParamType(paramType database.ParamType, direction int) database.ParamType {
+	index := slices.Index(database.ParamTypes, paramType)
+	count := len(database.ParamTypes)
+	return database.ParamTypes[(index+direction+count)%count]
+}
+
+// submit checks the values can be converted to their types before calling onSubmit,
+// an invalid value is reported in the form so it can be corrected
+func (p *ParamsView) submit() {
+	for i, param := range p.params {
+		if _, err := param.Type.Convert(param.Value); err != nil {
+			p.selected = i
+			p.view.Subtitle = err.Error()
+			return
+		}
+	}
+	params := slices.Clone(p.params)
+	p.hide(p.g, p.view)
+	p.onSubmit(params)
+}
+
+// Position places the form over the center of the screen when it is shown
+// and closes it when another view was selected
+func (p *ParamsView) Position(maxX, maxY int) {
+	if !p.view.Visible {
+		return
+	}
+	if current := p.g.CurrentView(); current != nil && current.Name() != p.name {
+		p.view.Visible = false
+		p.view.Editable = false
+		p.g.SetViewOnBottom(p.name)
+		return
+	}
+	top := max(maxY/2-len(p.params)/2-1, 1)
+	bottom := min(top+len(p.params)+1, maxY-2)
+	p.g.SetView(p.name, maxX/6, top, maxX*5/6, bottom, 0)
+}
+
+func (p *ParamsView) Paint() {
+	if !p.view.Visible {
+		return
+	}
+	nameWidth := 0
+	for _, param := range p.params {
+		nameWidth = max(nameWidth, runewidth.StringWidth(param.Name))
+	}
+	p.view.Clear()
+	for i, param := range p.params {
+		marker := "  "
+		if i == p.selected {
+			marker = "> "
+		}
+		value := param.Value
+		if param.Type == database.ParamNull {
+			value = grey("NULL")
+		} else if i == p.selected {
+			value += "▏"
+		}
+		line := fmt.Sprintf("%s%s  %-9s %s", marker, runewidth.FillRight(param.Name, nameWidth), "["+string(param.Type)+"]", value)
+		if i == p.selected {
+			line = "\x1b[1m" + line + "\x1b[0m"
+		}
+		p.view.WriteString(line)
+		if i < len(p.params)-1 {
+			p.view.WriteString("\n")
+		}
+	}
+}
+
+// withParameters asks for the values of the parameters of query when it has any,
+// the values used the last time query was executed are filled in
+// onReady is called with the values, or immediately when query has no parameters
+func (c *databaseContext) withParameters(query database.Query, onReady func(params []paramValue)) {
+	names, err := query.Parameters(c.db.Dialect())
+	if c.HandleError(err) {
+		return
+	}
+	if len(names) == 0 {
+		onReady(nil)
+		return
+	}
+	c.paramsView.Show(query, names, c.historyPane.LastParams(query), onReady)
+}
+
+// formatParams describes params for the history
+func formatParams(params []paramValue) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		parts[i] = param.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,256 @@
+package _databaseLayout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	"github.com/Kavantix/lazysql/internal/gui"
+)
+
+// expensiveNodes is the amount of nodes that are highlighted as most expensive
+const expensiveNodes = 3
+
+// PlanView shows the execution plan of a query as a collapsible tree
+type PlanView struct {
+	g            *gocui.Gui
+	pane         *gui.Pane[*planLine]
+	plan         *database.Plan
+	collapsed    map[*database.PlanNode]bool
+	previousView string
+}
+
+// planLine is a node of the plan as it is shown in the tree
+type planLine struct {
+	node  *database.PlanNode
+	depth int
+	// rank is the position of the node in the most expensive nodes, -1 when it is not one of them
+	rank      int
+	collapsed bool
+	// share is the part of the total cost spent in the node itself
+	share float64
+}
+
+func (l *planLine) String() string {
+	node := l.node
+	marker := " "
+	if len(node.Children) > 0 {
+		marker = "▾"
+		if l.collapsed {
+			marker = "▸"
+		}
+	}
+	operation := node.Operation
+	switch l.rank {
+	case -1:
+	case 0:
+		operation = red(operation)
+	default:
+		operation = yellow(operation)
+	}
+	line := strings.Repeat("  ", l.depth) + marker + " " + operation
+	if node.Detail != "" {
+		line += " " + grey(node.Detail)
+	}
+	stats := []string{}
+	if node.HasEstimate && node.HasActual {
+		stats = append(stats, fmt.Sprintf("rows %s/%s", formatAmount(node.EstimatedRows), formatAmount(node.ActualRows)))
+	} else if node.HasEstimate {
+		stats = append(stats, "rows "+formatAmount(node.EstimatedRows))
+	} else if node.HasActual {
+		stats = append(stats, "rows "+formatAmount(node.ActualRows))
+	}
+	if node.HasEstimate && node.Cost > 0 {
+		stats = append(stats, fmt.Sprintf("cost %.2f", node.Cost))
+	}
+	if node.HasActual {
+		stats = append(stats, fmt.Sprintf("time %.3fms", node.Time))
+	}
+	if l.rank >= 0 {
+		stats = append(stats, fmt.Sprintf("self %.0f%%", l.share*100))
+	}
+	if len(stats) > 0 {
+		line += "  (" + strings.Join(stats, ", ") + ")"
+	}
+	return line
+}
+
+func (l *planLine) EqualsPaneable(other gui.Paneable) bool {
+	return l == other
+}
+
+// formatAmount formats a row count without decimals when it is whole
+func formatAmount(amount float64) string {
+	if amount == float64(int64(amount)) {
+		return fmt.Sprint(int64(amount))
+	}
+	return fmt.Sprintf("%.1f", amount)
+}
+
+func red(text string) string {
+	// choose color mode ; 256 color mode ; bright red ; bold
+	return fmt.Sprintf("\x1b[38;5;9;1m%s\x1b[0m", text)
+}
+
+func yellow(text string) string {
+	// choose color mode ; 256 color mode ; bright yellow ; bold
+	return fmt.Sprintf("\x1b[38;5;11;1m%s\x1b[0m", text)
+}
+
+func grey(text string) string {
+	// choose color mode ; 256 color mode ; grey
+	return fmt.Sprintf("\x1b[38;5;245m%s\x1b[0m", text)
+}
+
+func NewPlanView(g *gocui.Gui) *PlanView {
+	p := &PlanView{
+		g:    g,
+		pane: gui.NewPane[*planLine](g, "Plan"),
+	}
+	p.pane.View.Visible = false
+	g.SetViewOnBottom(p.pane.Name)
+	p.pane.OnSelectItem(p.toggle)
+	checkErr(p.pane.SetKeybinding('q', p.hide))
+	checkErr(p.pane.SetKeybinding('h', p.collapseUnderCursor(true)))
+	checkErr(p.pane.SetKeybinding('l', p.collapseUnderCursor(false)))
+	checkErr(g.SetKeybinding(p.pane.Name, gocui.KeyEnter, gocui.ModNone, func(g *gocui.Gui, v *gocui.View) error {
+		if line, ok := p.pane.ItemUnderCursor(); ok && !p.pane.View.Editable {
+			p.toggle(line)
+		}
+		return nil
+	}))
+	return p
+}
+
+// Show shows plan as the plan of the query described by caption until the tree is closed
+func (p *PlanView) Show(caption string, plan *database.Plan) {
+	p.plan = plan
+	p.collapsed = map[*database.PlanNode]bool{}
+	p.pane.SetCursor(0)
+	p.refresh()
+	explain := "EXPLAIN"
+	if plan.Analyzed {
+		explain = "EXPLAIN ANALYZE"
+	}
+	p.pane.View.Title = fmt.Sprintf("%s %s", explain, caption)
+	p.pane.View.Subtitle = "space: collapse, h/l: collapse/expand, q: close"
+	if current := p.g.CurrentView(); current != nil && current.Name() != p.pane.Name {
+		p.previousView = current.Name()
+	}
+	p.pane.View.Visible = true
+	p.g.SetViewOnTop(p.pane.Name)
+	p.pane.Select()
+}
+
+// refresh builds the lines of the nodes that are not hidden by a collapsed parent
+func (p *PlanView) refresh() {
+	ranks := map[*database.PlanNode]int{}
+	for i, node := range p.plan.MostExpensive(expensiveNodes) {
+		ranks[node] = i
+	}
+	total := 0.0
+	p.plan.Root.Walk(func(node *database.PlanNode, depth int) {
+		total += node.ExclusiveCost(p.plan.Analyzed)
+	})
+
+	lines := []*planLine{}
+	var add func(node *database.PlanNode, depth int)
+	add = func(node *database.PlanNode, depth int) {
+		line := &planLine{
+			node:      node,
+			depth:     depth,
+			rank:      -1,
+			collapsed: p.collapsed[node],
+		}
+		if rank, ok := ranks[node]; ok {
+			line.rank = rank
+			line.share = node.ExclusiveCost(p.plan.Analyzed) / total
+		}
+		lines = append(lines, line)
+		if line.collapsed {
+			return
+		}
+		for _, child := range node.Children {
+			add(child, depth+1)
+		}
+	}
+	add(p.plan.Root, 0)
+	p.pane.SetContent(lines)
+}
+
+func (p *PlanView) toggle(line *planLine) {
+	if len(line.node.Children) == 0 {
+		return
+	}
+	p.collapsed[line.node] = !p.collapsed[line.node]
+	p.refresh()
+}
+
+func (p *PlanView) collapseUnderCursor(collapse bool) func() {
+	return func() {
+		line, ok := p.pane.ItemUnderCursor()
+		if !ok || len(line.node.Children) == 0 || line.collapsed == collapse {
+			return
+		}
+		p.toggle(line)
+	}
+}
+
+func (p *PlanView) hide() {
+	p.pane.View.Visible = false
+	p.g.SetViewOnBottom(p.pane.Name)
+	if p.previousView != "" {
+		p.g.SetCurrentView(p.previousView)
+	}
+}
+
+// Position places the tree over the results when it is shown
+// and closes it when another view was selected
+func (p *PlanView) Position(left, top, right, bottom int) {
+	if !p.pane.View.Visible {
+		return
+	}
+	if current := p.g.CurrentView(); current != nil && current.Name() != p.pane.Name {
+		p.pane.View.Visible = false
+		p.g.SetViewOnBottom(p.pane.Name)
+		return
+	}
+	p.pane.Position(left, top, right, bottom)
+}
+
+func (p *PlanView) Paint() {
+	if !p.pane.View.Visible {
+		return
+	}
+	p.pane.Paint()
+}
+
+// ExplainQuery shows the plan of query,
+// analyzing a statement that writes is confirmed first since it is executed
+func (c *databaseContext) ExplainQuery(query database.Query, analyze bool) {
+	if analyze && query.IsWrite() {
+		c.Confirm("EXPLAIN ANALYZE executes the statement, run it anyway?", func() error {
+			c.explainQuery(query, analyze)
+			return nil
+		})
+		return
+	}
+	c.explainQuery(query, analyze)
+}
+
+func (c *databaseContext) explainQuery(query database.Query, analyze bool) {
+	c.Log("Explaining query")
+	go func() {
+		c.resultsPane.View.HasLoader = true
+		plan, err := c.db.Explain(query, analyze)
+		c.resultsPane.View.HasLoader = false
+		if c.HandleError(err) {
+			return
+		}
+		c.Gui().UpdateAsync(func(g *gocui.Gui) error {
+			c.planView.Show(firstLine(query), plan)
+			return nil
+		})
+	}()
+}
@@ -7,14 +7,15 @@ import (
 	"time"
 
 	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
 	"github.com/atotto/clipboard"
 	"github.com/mattn/go-runewidth"
 )
 
 type ResultsPane struct {
 	Name                     string
-	columnNames              []string
-	rows                     [][]string
+	columns                  []database.Column
+	rows                     [][]database.Cell
 	View                     *gocui.View
 	g                        *gocui.Gui
 	dirty                    bool
@@ -23,8 +24,35 @@ type ResultsPane struct {
 	cursorX, cursorY         int
 	amountOfVisibleColumns   int
 	columnContentView        *gocui.View
+	result                   *database.QueryResult
+	loadingMore              bool
+	onLoadMore               func(result *database.QueryResult)
+	progress                 func() database.Progress
+	tabs                     []*resultTab
+	currentTab               int
+	// tabRanges are the start and end x of each tab in the tab strip
+	tabRanges [][2]int
+	// moreRows and truncated track the rows of result that are not loaded,
+	// the result itself is not changed after the driver returned it
+	moreRows, truncated bool
 }
 
+// resultTab is a result shown in the results pane that keeps its own position
+type resultTab struct {
+	caption          string
+	result           *database.QueryResult
+	columns          []database.Column
+	rows             [][]database.Cell
+	xOffset, yOffset int
+	cursorX, cursorY int
+	loadingMore      bool
+	moreRows         bool
+	truncated        bool
+}
+
+// maxCaptionWidth is the maximum width of a caption in the tab strip
+const maxCaptionWidth = 24
+
 func NewResultsPane(g *gocui.Gui) *ResultsPane {
 	view, _ := g.SetView("Results", 0, 0, 1, 1, 0)
 	view.Visible = true
@@ -36,8 +64,8 @@ func NewResultsPane(g *gocui.Gui) *ResultsPane {
 	view.Visible = true
 	r := &ResultsPane{
 		Name:              view.Name(),
-		columnNames:       make([]string, 0),
-		rows:              make([][]string, 0),
+		columns:           make([]database.Column, 0),
+		rows:              make([][]database.Cell, 0),
 		View:              view,
 		g:                 g,
 		dirty:             true,
@@ -64,6 +92,8 @@ func NewResultsPane(g *gocui.Gui) *ResultsPane {
 	g.SetKeybinding("", 'j', gocui.ModAlt, r.moveDown)
 	g.SetKeybinding(r.Name, 'k', gocui.ModAlt, r.moveUp)
 	g.SetKeybinding(r.Name, 'K', gocui.ModNone, r.showColumnContent)
+	g.SetKeybinding(r.Name, '[', gocui.ModNone, r.previousTab)
+	g.SetKeybinding(r.Name, ']', gocui.ModNone, r.nextTab)
 	g.SetKeybinding(r.columnContentView.Name(), gocui.KeyEsc, gocui.ModNone, r.hideColumnContent)
 	g.SetKeybinding(r.columnContentView.Name(), gocui.MouseLeft, gocui.ModNone, r.hideColumnContent)
 	g.SetKeybinding(r.columnContentView.Name(), gocui.MouseLeft, gocui.ModMouseCtrl, r.hideColumnContent)
@@ -79,8 +109,9 @@ func (r *ResultsPane) showColumnContent(g *gocui.Gui, v *gocui.View) error {
 		return nil
 	}
 
-	r.columnContentView.Title = fmt.Sprintf("#%d %s", r.cursorY+1, r.columnNames[r.cursorX])
-	content := strings.Split(strings.ReplaceAll(r.rows[r.cursorY][r.cursorX], "\r", ""), "\n")
+	column := r.columns[r.cursorX]
+	r.columnContentView.Title = fmt.Sprintf("#%d %s (%s)", r.cursorY+1, column.Name, strings.ToLower(column.DatabaseType))
+	content := strings.Split(r.rows[r.cursorY][r.cursorX].String(), "\n")
 	r.columnContentView.Clear()
 	maxLength := len(fmt.Sprintf("%d", len(content)))
 	for i, line := range content {
@@ -138,8 +169,8 @@ func (r *ResultsPane) focus(g *gocui.Gui, v *gocui.View) error {
 }
 
 func (r *ResultsPane) setXOffset(offset int) {
-	if offset > len(r.columnNames)-1 {
-		offset = len(r.columnNames) - 1
+	if offset > len(r.columns)-1 {
+		offset = len(r.columns) - 1
 	}
 	if offset < 0 {
 		offset = 0
@@ -196,19 +227,24 @@ func (r *ResultsPane) moveUp(g *gocui.Gui, v *gocui.View) error {
 
 func (r *ResultsPane) mouseDown(g *gocui.Gui, v *gocui.View) (err error) {
 	r.focus(g, v)
-	if len(r.columnNames) <= 0 {
+	if len(r.columns) <= 0 {
 		return
 	}
 	cx, cy := v.Cursor()
-	if cy <= 1 {
+	tabStripHeight := r.tabStripHeight()
+	if cy < tabStripHeight {
+		r.selectTabAt(cx)
+		return
+	}
+	if cy <= 1+tabStripHeight {
 		return
 	}
 	if cx <= 4 {
 		return
 	}
-	cy -= 2
+	cy -= 2 + tabStripHeight
 
-	header, err := v.Line(0)
+	header, err := v.Line(tabStripHeight)
 	if err != nil {
 		return
 	}
@@ -227,27 +263,236 @@ func (r *ResultsPane) mouseDown(g *gocui.Gui, v *gocui.View) (err error) {
 	return
 }
 
+// Clear removes all results
 func (r *ResultsPane) Clear() {
-	r.SetContent([]string{}, [][]string{})
+	r.g.Update(func(g *gocui.Gui) error {
+		r.tabs = nil
+		r.currentTab = 0
+		r.setContent([]database.Column{}, [][]database.Cell{})
+		r.result = nil
+		r.updateTitle()
+		return nil
+	})
+}
+
+// OnLoadMore sets the callback that is called when the cursor nears the last loaded row
+// of a result that has more rows available
+// The callback is expected to call AppendRows when done, even if loading failed
+func (r *ResultsPane) OnLoadMore(callback func(result *database.QueryResult)) {
+	r.onLoadMore = callback
+}
+
+// OnProgress sets the callback that reports the progress of the running query while the loader is shown
+func (r *ResultsPane) OnProgress(callback func() database.Progress) {
+	r.progress = callback
+}
+
+// Tab is a result together with the caption shown for it in the tab strip
+type Tab struct {
+	Caption string
+	Result  *database.QueryResult
+}
+
+// AddResults adds a tab for each result and shows the last one
+// caption describes where the results came from, like the statement that produced them
+// More rows are requested through OnLoadMore
+func (r *ResultsPane) AddResults(caption string, results []*database.QueryResult) {
+	tabs := make([]Tab, len(results))
+	for i, result := range results {
+		tabs[i] = Tab{Caption: caption, Result: result}
+		if len(results) > 1 {
+			tabs[i].Caption = fmt.Sprintf("%s #%d", caption, i+1)
+		}
+	}
+	r.AddTabs(tabs...)
+}
+
+// AddTabs adds tabs and shows the last one
+func (r *ResultsPane) AddTabs(tabs ...Tab) {
+	r.g.Update(func(g *gocui.Gui) error {
+		r.addTabs(tabs)
+		r.showTab(len(r.tabs) - 1)
+		return nil
+	})
+}
+
+// AppendTabs adds tabs after the existing ones without changing the tab that is shown,
+// the first of them is shown when there were no tabs yet
+func (r *ResultsPane) AppendTabs(tabs ...Tab) {
+	r.g.Update(func(g *gocui.Gui) error {
+		existing := len(r.tabs)
+		r.addTabs(tabs)
+		if existing == 0 {
+			r.showTab(0)
+		} else {
+			// The tab strip appears once there is more than one tab
+			r.dirty = true
+			r.updateTitle()
+		}
+		return nil
+	})
+}
+
+func (r *ResultsPane) addTabs(tabs []Tab) {
+	for _, tab := range tabs {
+		r.tabs = append(r.tabs, &resultTab{
+			caption:  tab.Caption,
+			result:   tab.Result,
+			columns:  tab.Result.Columns,
+			rows:     tab.Result.Rows,
+			moreRows: tab.Result.HasMore,
+		})
+	}
+}
+
+// AppendRows adds rows that were fetched for result
+// hasMore and truncated are what the driver reported about the rows that are left
+// Rows for a result that is no longer shown are ignored
+func (r *ResultsPane) AppendRows(result *database.QueryResult, rows [][]database.Cell, hasMore, truncated bool) {
+	r.g.Update(func(g *gocui.Gui) error {
+		if result == r.result {
+			r.loadingMore = false
+			r.moreRows, r.truncated = hasMore, truncated
+			r.rows = append(r.rows, rows...)
+			r.dirty = true
+			r.updateTitle()
+			return nil
+		}
+		for _, tab := range r.tabs {
+			if tab.result == result {
+				tab.loadingMore = false
+				tab.moreRows, tab.truncated = hasMore, truncated
+				tab.rows = append(tab.rows, rows...)
+			}
+		}
+		return nil
+	})
+}
+
+// saveTab stores the position in the current tab
+// Nothing is stored when the tab is not shown yet, like right after Clear
+func (r *ResultsPane) saveTab() {
+	if r.currentTab >= len(r.tabs) || r.tabs[r.currentTab].result != r.result {
+		return
+	}
+	tab := r.tabs[r.currentTab]
+	tab.rows = r.rows
+	tab.xOffset, tab.yOffset = r.xOffset, r.yOffset
+	tab.cursorX, tab.cursorY = r.cursorX, r.cursorY
+	tab.loadingMore = r.loadingMore
+	tab.moreRows, tab.truncated = r.moreRows, r.truncated
 }
 
-func (r *ResultsPane) SetContent(columnNames []string, rows [][]string) (err error) {
-	if len(rows) > 0 && len(columnNames) != len(rows[0]) {
+// showTab shows the tab at index where it was left
+func (r *ResultsPane) showTab(index int) {
+	if index < 0 || index >= len(r.tabs) {
+		return
+	}
+	r.saveTab()
+	r.currentTab = index
+	tab := r.tabs[index]
+	r.result = tab.result
+	r.columns = tab.columns
+	r.rows = tab.rows
+	r.xOffset, r.yOffset = tab.xOffset, tab.yOffset
+	r.cursorX, r.cursorY = tab.cursorX, tab.cursorY
+	r.loadingMore = tab.loadingMore
+	r.moreRows, r.truncated = tab.moreRows, tab.truncated
+	r.dirty = true
+	r.updateTitle()
+}
+
+func (r *ResultsPane) previousTab(g *gocui.Gui, v *gocui.View) error {
+	r.showTab(r.currentTab - 1)
+	return nil
+}
+
+func (r *ResultsPane) nextTab(g *gocui.Gui, v *gocui.View) error {
+	r.showTab(r.currentTab + 1)
+	return nil
+}
+
+func (r *ResultsPane) selectTabAt(x int) {
+	for i, tabRange := range r.tabRanges {
+		if x >= tabRange[0] && x < tabRange[1] {
+			r.showTab(i)
+			return
+		}
+	}
+}
+
+// tabStripHeight is the amount of lines used by the tab strip,
+// which is only shown when there is more than one result
+func (r *ResultsPane) tabStripHeight() int {
+	if len(r.tabs) > 1 {
+		return 1
+	}
+	return 0
+}
+
+func (r *ResultsPane) hasMore() bool {
+	return r.result != nil && r.moreRows
+}
+
+func (r *ResultsPane) updateTitle() {
+	name := r.Name
+	if len(r.tabs) > 1 {
+		name = fmt.Sprintf("%s [%d/%d]", r.Name, r.currentTab+1, len(r.tabs))
+	}
+	duration := ""
+	if r.result != nil && r.result.Duration > 0 {
+		duration = " in " + database.FormatDuration(r.result.Duration)
+	}
+	switch {
+	case len(r.columns) == 0:
+		r.View.Title = name
+	case r.hasMore():
+		r.View.Title = fmt.Sprintf("%s (%d rows loaded%s, more available)", name, len(r.rows), duration)
+	case r.result != nil && r.truncated:
+		r.View.Title = fmt.Sprintf("%s (%d rows loaded%s, truncated)", name, len(r.rows), duration)
+	default:
+		r.View.Title = fmt.Sprintf("%s (%d rows%s)", name, len(r.rows), duration)
+	}
+}
+
+func (r *ResultsPane) loadMoreIfNeeded(offsetY int) {
+	if r.loadingMore || !r.hasMore() || r.onLoadMore == nil {
+		return
+	}
+	_, sy := r.View.Size()
+	if offsetY >= len(r.rows)-sy {
+		r.loadingMore = true
+		r.onLoadMore(r.result)
+	}
+}
+
+func (r *ResultsPane) SetContent(columns []database.Column, rows [][]database.Cell) (err error) {
+	if len(rows) > 0 && len(columns) != len(rows[0]) {
 		return errors.New("number of columns dont match")
 	}
 
 	r.g.Update(func(g *gocui.Gui) error {
-		r.dirty = true
-		r.columnNames = columnNames
-		r.rows = rows
-		r.setXOffset(0)
-		r.setYOffset(0)
-		r.setCursor(0, 0)
+		r.tabs = nil
+		r.currentTab = 0
+		r.setContent(columns, rows)
+		r.result = nil
+		r.updateTitle()
 		return nil
 	})
 	return nil
 }
 
+func (r *ResultsPane) setContent(columns []database.Column, rows [][]database.Cell) {
+	r.dirty = true
+	r.loadingMore = false
+	r.moreRows, r.truncated = false, false
+	r.columns = columns
+	r.rows = rows
+	r.setXOffset(0)
+	r.setYOffset(0)
+	r.setCursor(0, 0)
+}
+
 func (r *ResultsPane) Position(left, top, right, bottom int) {
 	r.View.Visible = true
 	if r.columnContentView.Visible && r.g.CurrentView() != r.columnContentView {
@@ -299,6 +544,13 @@ func (r *ResultsPane) Paint() {
 		r.View.SetWritePos(sx/2-5, sy/2-1)
 		r.View.WriteString("Loading ")
 		r.View.WriteString(str)
+		if r.progress != nil {
+			if progress := r.progress(); progress.Running {
+				text := progress.String()
+				r.View.SetWritePos(max(sx/2-runewidth.StringWidth(text)/2, 0), sy/2)
+				r.View.WriteString(grey(text))
+			}
+		}
 		r.dirty = true
 		return
 	}
@@ -306,14 +558,17 @@ func (r *ResultsPane) Paint() {
 		return
 	}
 	r.View.Clear()
-	if len(r.columnNames) == 0 {
+	sx, sy := r.View.Size()
+	r.paintTabStrip(sx)
+	if len(r.columns) == 0 {
+		r.dirty = false
 		return
 	}
-	sx, sy := r.View.Size()
+	sy -= r.tabStripHeight()
 
 	numberSize := 4
-	availableSize := sx - (len(r.columnNames) - 1) - (numberSize + 1)
-	columnWidth := availableSize / len(r.columnNames)
+	availableSize := sx - (len(r.columns) - 1) - (numberSize + 1)
+	columnWidth := availableSize / len(r.columns)
 	if columnWidth < 12 {
 		columnWidth = 12
 	}
@@ -329,8 +584,8 @@ func (r *ResultsPane) Paint() {
 		header.WriteString(boldBrightCyan(delimiter))
 		verticalDelimiter.WriteString(strings.Repeat("─", numberSize))
 		verticalDelimiter.WriteString(boldBrightCyan("┼"))
-		for i := r.xOffset; i < len(r.columnNames); i++ {
-			column := r.columnNames[i]
+		for i := r.xOffset; i < len(r.columns); i++ {
+			column := r.columns[i].Name
 			if len(column) > columnWidth {
 				header.WriteString(boldBrightCyan(column[:columnWidth]))
 			} else {
@@ -338,7 +593,7 @@ func (r *ResultsPane) Paint() {
 				header.WriteString(strings.Repeat(" ", columnWidth-len(column)))
 			}
 			verticalDelimiter.WriteString(boldBrightCyan(strings.Repeat("─", columnWidth)))
-			if i < len(r.columnNames)-1 {
+			if i < len(r.columns)-1 {
 				header.WriteString(boldBrightCyan(delimiter))
 				verticalDelimiter.WriteString(boldBrightCyan("┼"))
 			}
@@ -371,13 +626,15 @@ func (r *ResultsPane) Paint() {
 		line.WriteString(boldBrightCyan(string(delimiter)))
 		rowLength := r.rows[y]
 		for x := r.xOffset; x < len(rowLength); x++ {
-			column := r.rows[y][x]
+			value := r.rows[y][x]
 			cell.Reset()
 			// TODO: nicely visualise newlines
-			column = strings.ReplaceAll(strings.ReplaceAll(column, "\r", ""), "\n", "⏎")
+			column := strings.ReplaceAll(value.String(), "\n", "⏎")
 			length := runewidth.StringWidth(column)
 			if length > columnWidth {
 				cell.WriteString(runewidth.Truncate(column, columnWidth, ""))
+			} else if r.columns[x].Kind == database.KindNumber {
+				cell.WriteString(runewidth.FillLeft(column, columnWidth))
 			} else {
 				cell.WriteString(runewidth.FillRight(column, columnWidth))
 			}
@@ -388,11 +645,13 @@ func (r *ResultsPane) Paint() {
 				} else {
 					line.WriteString(styleSelectedCell(cell.String(), 236))
 				}
+			} else if value.Null {
+				line.WriteString(dimmed(cell.String()))
 			} else {
 				line.WriteString(cell.String())
 			}
 
-			if x < len(r.columnNames)-1 {
+			if x < len(r.columns)-1 {
 				line.WriteString(boldBrightCyan(delimiter))
 			}
 		}
@@ -407,6 +666,35 @@ func (r *ResultsPane) Paint() {
 	r.dirty = false
 }
 
+func (r *ResultsPane) paintTabStrip(width int) {
+	r.tabRanges = r.tabRanges[:0]
+	if r.tabStripHeight() == 0 {
+		return
+	}
+	strip := strings.Builder{}
+	x := 0
+	for i, tab := range r.tabs {
+		caption := strings.ReplaceAll(tab.caption, "\n", " ")
+		label := fmt.Sprintf(" %d: %s ", i+1, runewidth.Truncate(caption, maxCaptionWidth, "…"))
+		labelWidth := runewidth.StringWidth(label)
+		r.tabRanges = append(r.tabRanges, [2]int{x, x + labelWidth})
+		if i == r.currentTab {
+			strip.WriteString(styleSelectedCell(label, 0))
+		} else {
+			strip.WriteString(label)
+		}
+		x += labelWidth
+		if i < len(r.tabs)-1 {
+			strip.WriteString(boldBrightCyan("│"))
+			x += 1
+		}
+		if x >= width {
+			break
+		}
+	}
+	fmt.Fprintln(r.View, strip.String())
+}
+
 func (r *ResultsPane) Select() {
 	r.g.SetCurrentView(r.Name)
 }
@@ -420,8 +708,10 @@ func (r *ResultsPane) setCursor(offsetX, offsetY int) {
 		offsetY = 0
 	}
 
-	if offsetX > len(r.columnNames)-1 {
-		offsetX = len(r.columnNames) - 1
+	r.loadMoreIfNeeded(offsetY)
+
+	if offsetX > len(r.columns)-1 {
+		offsetX = len(r.columns) - 1
 	}
 
 	if offsetY > len(r.rows)-1 {
@@ -438,7 +728,7 @@ func (r *ResultsPane) setCursor(offsetX, offsetY int) {
 
 	_, sy := r.View.Size()
 
-	sy -= 2
+	sy -= 2 + r.tabStripHeight()
 	if r.cursorY-r.yOffset <= 0 {
 		r.yOffset = r.cursorY
 	} else if r.cursorY > sy+r.yOffset-1 {
@@ -458,10 +748,15 @@ func boldBrightCyan(text string) string {
 }
 
 func grey(text string) string {
-	// choose color mode ; 256 color mode ; dark blue ; bold
+	// choose color mode ; 256 color mode ; light grey
 	return fmt.Sprintf("\x1b[38;5;7m%s\x1b[38;5;7m", text)
 }
 
+func dimmed(text string) string {
+	// choose color mode ; 256 color mode ; dark grey
+	return fmt.Sprintf("\x1b[38;5;243m%s\x1b[38;5;7m", text)
+}
+
 func styleSelectedCell(text string, currentBg int) string {
 	// choose color mode ; 256 color mode ; color ; bold
 	return fmt.Sprintf("\x1b[48;5;5;38;5;15;1m%s\x1b[48;5;%d;38;5;7m", text, currentBg)
@@ -470,6 +765,6 @@ func styleSelectedCell(text string, currentBg int) string {
 func (r *ResultsPane) copyCell(g *gocui.Gui, v *gocui.View) error {
 	// TODO check if this is possible(if not data)
 	// TODO show a message
-	clipboard.WriteAll(r.rows[r.cursorY][r.cursorX])
+	clipboard.WriteAll(r.rows[r.cursorY][r.cursorX].String())
 	return nil
 }
@@ -0,0 +1,101 @@
+package _databaseLayout
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/database"
+	. "github.com/Kavantix/lazysql/internal/layouts/database/results"
+)
+
+func (c *databaseContext) showStructure() {
+	item, ok := c.tablesPane.ItemUnderCursor()
+	if !ok {
+		return
+	}
+	table := item.Table
+	if table.Kind().IsRoutine() {
+		c.ShowInfo(fmt.Sprintf("A %s has no structure, select it to show its definition", table.Kind()))
+		return
+	}
+	c.Log(fmt.Sprintf("Describing table %s", table.DisplayString()))
+	c.resultsPane.Clear()
+	go func() {
+		c.resultsPane.View.HasLoader = true
+		structure, err := c.db.Describe(table)
+		c.resultsPane.View.HasLoader = false
+		if c.HandleError(err) {
+			return
+		}
+		c.resultsPane.AddTabs(structureTabs(structure)...)
+		c.Gui().UpdateAsync(func(g *gocui.Gui) error {
+			c.resultsPane.Select()
+			return nil
+		})
+	}()
+}
+
+// structureTabs shows the columns, indexes and foreign keys of a table as results
+func structureTabs(structure *database.TableStructure) []Tab {
+	columns := textResult("name", "type", "nullable", "default", "key", "comment")
+	for _, column := range structure.Columns {
+		key := ""
+		if structure.IsPrimaryKey(column.Name) {
+			key = "PRI"
+		}
+		columns.Rows = append(columns.Rows, []database.Cell{
+			{Value: column.Name},
+			{Value: column.Type},
+			{Value: yesNo(column.Nullable)},
+			{Value: column.Default, Null: !column.HasDefault},
+			{Value: key},
+			{Value: column.Comment},
+		})
+	}
+
+	indexes := textResult("name", "columns", "unique", "primary")
+	for _, index := range structure.Indexes {
+		indexes.Rows = append(indexes.Rows, []database.Cell{
+			{Value: index.Name},
+			{Value: strings.Join(index.Columns, ", ")},
+			{Value: yesNo(index.Unique)},
+			{Value: yesNo(index.Primary)},
+		})
+	}
+
+	foreignKeys := textResult("name", "columns", "references", "on update", "on delete")
+	for _, key := range structure.ForeignKeys {
+		foreignKeys.Rows = append(foreignKeys.Rows, []database.Cell{
+			{Value: key.Name},
+			{Value: strings.Join(key.Columns, ", ")},
+			{Value: fmt.Sprintf("%s (%s)", key.ReferencedTable, strings.Join(key.ReferencedColumns, ", "))},
+			{Value: key.OnUpdate},
+			{Value: key.OnDelete},
+		})
+	}
+
+	return []Tab{
+		{Caption: "Columns", Result: columns},
+		{Caption: "Indexes", Result: indexes},
+		{Caption: "Foreign keys", Result: foreignKeys},
+	}
+}
+
+func textResult(columnNames ...string) *database.QueryResult {
+	result := &database.QueryResult{
+		Columns: make([]database.Column, len(columnNames)),
+		Rows:    [][]database.Cell{},
+	}
+	for i, name := range columnNames {
+		result.Columns[i] = database.Column{Name: name, DatabaseType: "TEXT"}
+	}
+	return result
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "YES"
+	}
+	return "NO"
+}
@@ -25,6 +25,28 @@ func (c *LayoutContext) ShowConfigLayout() {
 	configLayout.Show(c)
 }
 
-func (c *LayoutContext) ShowDatabaseLayout(db database.Driver, databases []database.Database) {
-	databaseLayout.Show(c, db, databases)
+func (c *LayoutContext) ShowDatabaseLayout(db database.Driver, databases []database.Database, selection gui.Selection) {
+	databaseLayout.Show(c, db, databases, selection)
+}
+
+// Open connects to host and shows the database layout with selection selected,
+// the config layout is shown instead when connecting fails
+func (c *LayoutContext) Open(host configLayout.Host, selection gui.Selection) {
+	shown := false
+	if configLayout.NeedsUnlock(host) {
+		// The passphrase is asked on top of the config layout
+		c.ShowConfigLayout()
+		shown = true
+	}
+	configLayout.Unlock(c, host, func() {
+		db, databases, err := configLayout.Connect(c, host)
+		if err != nil {
+			if !shown {
+				c.ShowConfigLayout()
+			}
+			c.ShowError(err.Error())
+			return
+		}
+		c.ShowDatabaseLayout(db, databases, selection)
+	})
 }
@@ -1,12 +1,19 @@
 package popup
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/Kavantix/gocui"
+	"github.com/Kavantix/lazysql/internal/gui"
 	"github.com/mattn/go-runewidth"
 )
 
 const popupViewName = "Popup"
 
+// ViewName is the name of the view popups are shown in, which takes the focus while it is shown
+const ViewName = popupViewName
+
 type View struct {
 	title, message             string
 	g                          *gocui.Gui
@@ -15,6 +22,15 @@ type View struct {
 	visible                    bool
 	previouslySelectedViewName string
 	previousHighlightValue     bool
+	choices                    []gui.Choice
+	prompt                     *prompt
+}
+
+// prompt is the text typed into a popup shown with Prompt
+type prompt struct {
+	masked   bool
+	input    []rune
+	onSubmit func(value string)
 }
 
 func New(g *gocui.Gui) (*View, error) {
@@ -27,6 +43,7 @@ func New(g *gocui.Gui) (*View, error) {
 	v.view.Visible = false
 	g.SetViewOnBottom(popupViewName)
 	v.view.Wrap = true
+	v.view.Editor = v
 	v.g.SetKeybinding(popupViewName, gocui.KeyEsc, gocui.ModNone, v.hide)
 
 	if err != nil && err != gocui.ErrUnknownView {
@@ -37,7 +54,30 @@ func New(g *gocui.Gui) (*View, error) {
 }
 
 func (v *View) Show(title, message string, color gocui.Attribute) {
+	v.ShowChoices(title, message, color, nil)
+}
+
+// ShowChoices shows a popup with a key for every choice
+// Pressing the key of a choice hides the popup and calls its OnChoose
+func (v *View) ShowChoices(title, message string, color gocui.Attribute, choices []gui.Choice) {
+	if len(choices) > 0 {
+		labels := make([]string, len(choices))
+		for i, choice := range choices {
+			labels[i] = fmt.Sprintf("[%c] %s", choice.Key, choice.Label)
+		}
+		message += "\n\n" + strings.Join(labels, "  ")
+	}
 	v.g.Update(func(g *gocui.Gui) error {
+		v.deleteChoiceKeybindings()
+		v.prompt = nil
+		v.view.Editable = false
+		v.choices = choices
+		for _, choice := range choices {
+			v.g.SetKeybinding(popupViewName, choice.Key, gocui.ModNone, func(g *gocui.Gui, _ *gocui.View) error {
+				v.Hide()
+				return choice.OnChoose()
+			})
+		}
 		v.g.SetKeybinding("", gocui.MouseLeft, gocui.ModNone, v.hide)
 		v.g.SetKeybinding("", gocui.MouseRight, gocui.ModNone, v.hide)
 		v.g.SetKeybinding("", gocui.MouseMiddle, gocui.ModNone, v.hide)
@@ -49,6 +89,58 @@ func (v *View) Show(title, message string, color gocui.Attribute) {
 	})
 }
 
+// Prompt shows a popup to type a value in, onSubmit is called with the value when enter is pressed
+// When masked the value is shown as asterisks, e.g. for passwords
+func (v *View) Prompt(title, message string, color gocui.Attribute, masked bool, onSubmit func(value string)) {
+	v.ShowChoices(title, message, color, nil)
+	v.g.Update(func(g *gocui.Gui) error {
+		v.prompt = &prompt{
+			masked:   masked,
+			onSubmit: onSubmit,
+		}
+		v.view.Editable = true
+		return nil
+	})
+}
+
+// Edit implements gocui.Editor.
+func (v *View) Edit(_ *gocui.View, key gocui.Key, ch rune, mod gocui.Modifier) {
+	prompt := v.prompt
+	if prompt == nil {
+		return
+	}
+	switch key {
+	case gocui.KeyEnter:
+		v.Hide()
+		prompt.onSubmit(string(prompt.input))
+	case gocui.KeyEsc:
+		v.Hide()
+	case gocui.KeyBackspace, gocui.KeyBackspace2:
+		if len(prompt.input) > 0 {
+			prompt.input = prompt.input[:len(prompt.input)-1]
+		}
+	case gocui.KeySpace:
+		prompt.input = append(prompt.input, ' ')
+	case 0:
+		if ch != 0 {
+			prompt.input = append(prompt.input, ch)
+		}
+	}
+}
+
+// content is the text shown in the popup, including what is typed into a prompt
+func (v *View) content() string {
+	content := " " + strings.ReplaceAll(v.message, "\n", "\n ")
+	if v.prompt != nil {
+		input := string(v.prompt.input)
+		if v.prompt.masked {
+			input = strings.Repeat("*", len(v.prompt.input))
+		}
+		content += "\n\n > " + input
+	}
+	return content
+}
+
 func (v *View) hide(_ *gocui.Gui, _ *gocui.View) error {
 	v.Hide()
 	return nil
@@ -59,17 +151,33 @@ func (v *View) Hide() {
 		v.g.DeleteKeybinding("", gocui.MouseLeft, gocui.ModNone)
 		v.g.DeleteKeybinding("", gocui.MouseRight, gocui.ModNone)
 		v.g.DeleteKeybinding("", gocui.MouseMiddle, gocui.ModNone)
+		v.deleteChoiceKeybindings()
+		v.prompt = nil
+		v.view.Editable = false
 		v.visible = false
 		return nil
 	})
 }
 
+func (v *View) deleteChoiceKeybindings() {
+	for _, choice := range v.choices {
+		v.g.DeleteKeybinding(popupViewName, choice.Key, gocui.ModNone)
+	}
+	v.choices = nil
+}
+
 func (v *View) Layout() {
 	g := v.g
 	if v.visible {
 		maxX, maxY := g.Size()
 		v.view.Visible = true
-		width := runewidth.StringWidth(v.message) + 4
+		width := 0
+		for _, line := range strings.Split(v.message, "\n") {
+			width = max(width, runewidth.StringWidth(line)+4)
+		}
+		if v.prompt != nil {
+			width = max(width, 40)
+		}
 		if width%2 != 0 {
 			width += 1
 		}
@@ -86,14 +194,19 @@ func (v *View) Layout() {
 		currentView := g.CurrentView()
 		if currentView.Name() != popupViewName {
 			v.view.Clear()
-			v.view.WriteString(" ")
-			v.view.WriteString(v.message)
+			v.view.WriteString(v.content())
 			v.view.Title = v.title
 			v.view.FrameColor = v.color
 			v.previousHighlightValue = g.Highlight
 			g.Highlight = false
 			g.SetCurrentView(popupViewName)
 			v.previouslySelectedViewName = currentView.Name()
+		} else if v.prompt != nil {
+			// The content changes while typing
+			v.view.Clear()
+			v.view.WriteString(v.content())
+			v.view.Title = v.title
+			v.view.FrameColor = v.color
 		}
 		contentHeight := v.view.ViewLinesHeight()
 		top, bottom := 4, maxY-4
@@ -0,0 +1,177 @@
+// Package secretstore keeps secrets in a file encrypted with a passphrase
+package secretstore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const version = 1
+
+// ErrWrongPassphrase is returned when a store cannot be decrypted with the passphrase
+var ErrWrongPassphrase = errors.New("wrong passphrase for the secret store")
+
+// Store is a decrypted set of named secrets
+type Store struct {
+	path    string
+	key     []byte
+	kdf     kdf
+	secrets map[string]string
+}
+
+// file is the encrypted form of a store as it is written to disk
+type file struct {
+	Version    int    `json:"version"`
+	Kdf        kdf    `json:"kdf"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// kdf are the scrypt parameters used to derive the key from the passphrase
+type kdf struct {
+	Salt []byte `json:"salt"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+}
+
+func (k kdf) key(passphrase string) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), k.Salt, k.N, k.R, k.P, 32)
+}
+
+// Exists reports whether there is a store at path
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Open decrypts the store at path with passphrase,
+// an empty store is returned when there is no file at path yet
+func Open(path, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, errors.New("the passphrase cannot be empty")
+	}
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return create(path, passphrase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var encrypted file
+	if err := json.Unmarshal(content, &encrypted); err != nil {
+		return nil, fmt.Errorf("invalid secret store %s: %w", path, err)
+	}
+	if encrypted.Version != version {
+		return nil, fmt.Errorf("unsupported secret store version %d", encrypted.Version)
+	}
+	key, err := encrypted.Kdf.key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAead(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	store := &Store{
+		path: path,
+		key:  key,
+		kdf:  encrypted.Kdf,
+	}
+	if err := json.Unmarshal(plaintext, &store.secrets); err != nil {
+		return nil, fmt.Errorf("invalid secret store %s: %w", path, err)
+	}
+	return store, nil
+}
+
+func create(path, passphrase string) (*Store, error) {
+	params := kdf{
+		Salt: make([]byte, 16),
+		N:    1 << 15,
+		R:    8,
+		P:    1,
+	}
+	if _, err := rand.Read(params.Salt); err != nil {
+		return nil, err
+	}
+	key, err := params.key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{
+		path:    path,
+		key:     key,
+		kdf:     params,
+		secrets: map[string]string{},
+	}, nil
+}
+
+func newAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get returns the secret called name
+func (s *Store) Get(name string) (string, bool) {
+	secret, ok := s.secrets[name]
+	return secret, ok
+}
+
+// Set changes the secret called name, Save has to be called to write it to disk
+func (s *Store) Set(name, secret string) {
+	s.secrets[name] = secret
+}
+
+// Names returns the names of all secrets in the store, sorted
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.secrets))
+	for name := range s.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the store and writes it to disk, readable only by the current user
+func (s *Store) Save() error {
+	plaintext, err := json.Marshal(s.secrets)
+	if err != nil {
+		return err
+	}
+	aead, err := newAead(s.key)
+	if err != nil {
+		return err
+	}
+	encrypted := file{
+		Version: version,
+		Kdf:     s.kdf,
+		Nonce:   make([]byte, aead.NonceSize()),
+	}
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Ciphertext = aead.Seal(nil, encrypted.Nonce, plaintext, nil)
+	content, err := json.MarshalIndent(encrypted, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.path, content, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(s.path, 0o600)
+}
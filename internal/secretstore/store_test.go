@@ -0,0 +1,53 @@
+package secretstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	if Exists(path) {
+		t.Fatalf("Store should not exist yet")
+	}
+	store, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Unexpected error creating store: %s", err)
+	}
+	store.Set("prod", "s3cret")
+	store.Set("staging", "other")
+	if err := store.Save(); err != nil {
+		t.Fatalf("Unexpected error saving store: %s", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Fatalf("Store should only be readable by the user, got %s", info.Mode().Perm())
+	}
+	content, _ := os.ReadFile(path)
+	if strings.Contains(string(content), "s3cret") {
+		t.Fatalf("Secret was written in plaintext:\n%s", content)
+	}
+
+	reopened, err := Open(path, "correct horse")
+	if err != nil {
+		t.Fatalf("Unexpected error opening store: %s", err)
+	}
+	if secret, ok := reopened.Get("prod"); !ok || secret != "s3cret" {
+		t.Fatalf("Wrong secret after reopening: `%s`", secret)
+	}
+	if names := strings.Join(reopened.Names(), ","); names != "prod,staging" {
+		t.Fatalf("Wrong names: %s", names)
+	}
+
+	_, err = Open(path, "wrong")
+	if !errors.Is(err, ErrWrongPassphrase) {
+		t.Fatalf("Expected ErrWrongPassphrase, got %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package sshtunnel opens connections to databases through an ssh server,
+// like a bastion host that is the only way to reach the database
+package sshtunnel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Config describes the ssh server that connections are tunneled through
+type Config struct {
+	Host string
+	// Port defaults to 22
+	Port int
+	// User defaults to the current user
+	User string
+	// KeyFile is the path of the private key to authenticate with,
+	// the keys of the ssh agent are used when empty
+	KeyFile string
+	// KnownHostsFile is used to verify the key of the server, defaults to ~/.ssh/known_hosts
+	KnownHostsFile string
+}
+
+// Tunnel is a connection to an ssh server that database connections are dialed through
+type Tunnel struct {
+	client *ssh.Client
+}
+
+// Open connects and authenticates to the ssh server
+// The key of the server has to be in the known hosts file
+func Open(config Config) (*Tunnel, error) {
+	if config.Host == "" {
+		return nil, errors.New("ssh host cannot be empty")
+	}
+	if config.Port == 0 {
+		config.Port = 22
+	}
+	if config.User == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine ssh user: %w", err)
+		}
+		config.User = current.Username
+	}
+	hostKeyCallback, err := hostKeyCallback(config.KnownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	auth, closeAuth, err := authMethod(config.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	defer closeAuth()
+
+	address := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
+	client, err := ssh.Dial("tcp", address, &ssh.ClientConfig{
+		User:            config.User,
+		Auth:            []ssh.AuthMethod{auth},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         10 * time.Second,
+	})
+	if err != nil {
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("ssh host %s is not a known host, connect to it once using ssh to trust its key", address)
+		}
+		return nil, fmt.Errorf("failed to connect to ssh host %s: %w", address, err)
+	}
+	return &Tunnel{client: client}, nil
+}
+
+// DialContext opens a connection to address as seen from the ssh server
+func (t *Tunnel) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return t.client.DialContext(ctx, network, address)
+}
+
+// Close closes the connection to the ssh server and all connections dialed through it
+func (t *Tunnel) Close() error {
+	return t.client.Close()
+}
+
+func hostKeyCallback(knownHostsFile string) (ssh.HostKeyCallback, error) {
+	if knownHostsFile == "" {
+		homedir, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHostsFile = filepath.Join(homedir, ".ssh", "known_hosts")
+	}
+	knownHostsFile, err := expandHome(knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	callback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known hosts: %w", err)
+	}
+	return callback, nil
+}
+
+// authMethod authenticates using keyFile, or using the ssh agent when keyFile is empty
+// close releases the connection to the agent and should be called once authenticated
+func authMethod(keyFile string) (method ssh.AuthMethod, close func(), err error) {
+	if keyFile != "" {
+		keyFile, err := expandHome(keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		pemBytes, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read ssh key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(pemBytes)
+		if err != nil {
+			var passphraseErr *ssh.PassphraseMissingError
+			if errors.As(err, &passphraseErr) {
+				return nil, nil, fmt.Errorf("ssh key %s is protected by a passphrase, add it to the ssh agent instead", keyFile)
+			}
+			return nil, nil, fmt.Errorf("failed to parse ssh key: %w", err)
+		}
+		return ssh.PublicKeys(signer), func() {}, nil
+	}
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil, errors.New("no ssh key file configured and no ssh agent is running")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to ssh agent: %w", err)
+	}
+	return ssh.PublicKeysCallback(agent.NewClient(conn).Signers), func() { conn.Close() }, nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory like a shell would
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homedir, path[1:]), nil
+}
@@ -0,0 +1,227 @@
+package sshtunnel
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// testServer is an in-process ssh server that only supports forwarding connections
+type testServer struct {
+	address string
+	hostKey ssh.PublicKey
+}
+
+func startTestServer(t *testing.T, clientKey ssh.PublicKey) testServer {
+	_, hostPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate host key: %s", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPrivate)
+	if err != nil {
+		t.Fatalf("Failed to create host signer: %s", err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "tester" && bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, io.EOF
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+	return testServer{address: listener.Addr().String(), hostKey: hostSigner.PublicKey()}
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	serverConn, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	defer serverConn.Close()
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "only forwarding is supported")
+			continue
+		}
+		target := struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}{}
+		if err := ssh.Unmarshal(newChannel.ExtraData(), &target); err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		targetConn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, err := newChannel.Accept()
+		if err != nil {
+			targetConn.Close()
+			continue
+		}
+		go ssh.DiscardRequests(channelRequests)
+		go func() {
+			io.Copy(channel, targetConn)
+			channel.Close()
+		}()
+		go func() {
+			io.Copy(targetConn, channel)
+			targetConn.Close()
+		}()
+	}
+}
+
+// startEchoServer starts a server that stands in for a database
+func startEchoServer(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				io.Copy(conn, conn)
+				conn.Close()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func writeClientKey(t *testing.T) (string, ssh.PublicKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate client key: %s", err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal client key: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write client key: %s", err)
+	}
+	sshPublic, err := ssh.NewPublicKey(public)
+	if err != nil {
+		t.Fatalf("Failed to convert client key: %s", err)
+	}
+	return path, sshPublic
+}
+
+func writeKnownHosts(t *testing.T, address string, key ssh.PublicKey) string {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(address)}, key) + "\n"
+	if err := os.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatalf("Failed to write known hosts: %s", err)
+	}
+	return path
+}
+
+func testConfig(t *testing.T, address string) Config {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		t.Fatalf("Invalid address: %s", err)
+	}
+	portNumber, _ := strconv.Atoi(port)
+	return Config{Host: host, Port: portNumber, User: "tester"}
+}
+
+func TestTunnel(t *testing.T) {
+	keyFile, clientKey := writeClientKey(t)
+	server := startTestServer(t, clientKey)
+	echoAddress := startEchoServer(t)
+
+	config := testConfig(t, server.address)
+	config.KeyFile = keyFile
+	config.KnownHostsFile = writeKnownHosts(t, server.address, server.hostKey)
+	tunnel, err := Open(config)
+	if err != nil {
+		t.Fatalf("Failed to open tunnel: %s", err)
+	}
+	defer tunnel.Close()
+
+	conn, err := tunnel.DialContext(context.Background(), "tcp", echoAddress)
+	if err != nil {
+		t.Fatalf("Failed to dial through tunnel: %s", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Failed to write: %s", err)
+	}
+	response := make([]byte, 4)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		t.Fatalf("Failed to read: %s", err)
+	}
+	if string(response) != "ping" {
+		t.Fatalf("Incorrect response `%s`", response)
+	}
+}
+
+func TestUnknownHost(t *testing.T) {
+	keyFile, clientKey := writeClientKey(t)
+	server := startTestServer(t, clientKey)
+	other := startTestServer(t, clientKey)
+
+	config := testConfig(t, server.address)
+	config.KeyFile = keyFile
+	config.KnownHostsFile = writeKnownHosts(t, other.address, other.hostKey)
+	if _, err := Open(config); err == nil || !strings.Contains(err.Error(), "not a known host") {
+		t.Fatalf("Expected an unknown host error, got %v", err)
+	}
+
+	config.KnownHostsFile = writeKnownHosts(t, server.address, other.hostKey)
+	if _, err := Open(config); err == nil {
+		t.Fatalf("Expected an error for a changed host key")
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	_, clientKey := writeClientKey(t)
+	otherKeyFile, _ := writeClientKey(t)
+	server := startTestServer(t, clientKey)
+
+	config := testConfig(t, server.address)
+	config.KeyFile = otherKeyFile
+	config.KnownHostsFile = writeKnownHosts(t, server.address, server.hostKey)
+	if _, err := Open(config); err == nil {
+		t.Fatalf("Expected an authentication error")
+	}
+}